@@ -23,6 +23,9 @@ type AWSTools struct {
 	params       *AWSToolsParams
 	cfg          aws.Config
 	s3Client     *s3.Client
+	presigner    *s3.PresignClient
+	uploader     *manager.Uploader
+	downloader   *manager.Downloader
 	mu           *sync.Mutex
 	queueWorkers int
 	exitWorkers  map[int]chan struct{}
@@ -47,7 +50,7 @@ func NewAWSTools(opts ...Options) (*AWSTools, error) {
 		)),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %s", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Create S3 client with custom options if needed
@@ -81,6 +84,9 @@ func NewAWSTools(opts ...Options) (*AWSTools, error) {
 		params:       params,
 		cfg:          cfg,
 		s3Client:     s3Client,
+		presigner:    s3.NewPresignClient(s3Client),
+		uploader:     newUploader(s3Client, params),
+		downloader:   newDownloader(s3Client, params),
 		queueWorkers: qWorkers,
 		exitWorkers:  make(map[int]chan struct{}),
 		lines:        make(map[string]int64),
@@ -119,29 +125,72 @@ func (a *AWSTools) UploadFileToS3WithOptions(bucket, fileName, filePath string,
 }
 
 func (a *AWSTools) UploadFileToS3WithContextAndOptions(ctx context.Context, bucket, fileName, filePath string, opts ...UploadOption) error {
+	return a.UploadFileToS3WithContextAndTransfer(ctx, bucket, fileName, filePath, UploadTransfer{}, opts...)
+}
+
+func (a *AWSTools) UploadFileToS3WithTransfer(bucket, fileName, filePath string, transfer UploadTransfer, opts ...UploadOption) error {
+	return a.UploadFileToS3WithContextAndTransfer(context.Background(), bucket, fileName, filePath, transfer, opts...)
+}
+
+// UploadFileToS3WithContextAndTransfer uploads filePath with the part size,
+// concurrency and progress reporting of transfer.
+func (a *AWSTools) UploadFileToS3WithContextAndTransfer(ctx context.Context, bucket, fileName, filePath string, transfer UploadTransfer, opts ...UploadOption) error {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file %q, %v", filePath, err)
+		return fmt.Errorf("failed to open file %q, %w", filePath, err)
 	}
 	defer file.Close()
 
-	uploader := manager.NewUploader(a.s3Client)
-
-	input := &s3.PutObjectInput{
+	return a.upload(ctx, applyUploadOptions(&s3.PutObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(fileName),
 		Body:   file,
+	}, opts), transfer)
+}
+
+// upload uploads input.Body, filling the SHA256 metadata and encrypting the
+// body when client encryption is configured.
+func (a *AWSTools) upload(ctx context.Context, input *s3.PutObjectInput, transfer UploadTransfer) error {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	size := bodySize(input.Body)
+	tracker := newProgressTracker(transfer.Progress, "upload", bucket, key, size)
+	if _, err := a.sendUpload(ctx, input, size, transfer, tracker); err != nil {
+		return err
 	}
 
-	for _, opt := range opts {
-		if opt != nil {
-			opt(input)
-		}
+	tracker.finish()
+	return nil
+}
+
+// sendUpload is upload with the progress reported to tracker. size is the
+// size of the body, or -1 when unknown.
+func (a *AWSTools) sendUpload(ctx context.Context, input *s3.PutObjectInput, size int64,
+	transfer UploadTransfer, tracker *progressTracker) (*manager.UploadOutput, error) {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	// UploadOption cannot fail, so the SSE-C key is checked on the input.
+	if err := checkEncodedSSECustomerKey(input.SSECustomerKey); err != nil {
+		return nil, err
+	}
+
+	if err := fillSHA256Metadata(input); err != nil {
+		return nil, fmt.Errorf("failed to hash object %q, %w", key, err)
 	}
 
-	_, err = uploader.Upload(ctx, input)
+	input.Body = newProgressReader(input.Body, tracker)
 
-	return err
+	if wrapper := a.params.ClientEncryption(); wrapper != nil {
+		if err := encryptUpload(ctx, wrapper, input, size); err != nil {
+			return nil, fmt.Errorf("failed to encrypt object %q, %w", key, err)
+		}
+	}
+
+	out, err := a.uploader.Upload(ctx, input, a.uploaderOptions(transfer, tracker))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file, %w", newOpError("PutObject", bucket, key, err))
+	}
+	return out, nil
 }
 
 func (a *AWSTools) DownloadFileFromS3(bucket, fileName, filePath string) error {
@@ -149,20 +198,104 @@ func (a *AWSTools) DownloadFileFromS3(bucket, fileName, filePath string) error {
 }
 
 func (a *AWSTools) DownloadFileFromS3WithContext(ctx context.Context, bucket, fileName, filePath string) error {
+	return a.DownloadFileFromS3WithContextAndOptions(ctx, bucket, fileName, filePath)
+}
+
+func (a *AWSTools) DownloadFileFromS3WithOptions(bucket, fileName, filePath string, opts ...DownloadOption) error {
+	return a.DownloadFileFromS3WithContextAndOptions(context.Background(), bucket, fileName, filePath, opts...)
+}
+
+func (a *AWSTools) DownloadFileFromS3WithContextAndOptions(ctx context.Context, bucket, fileName, filePath string, opts ...DownloadOption) error {
+	params := newDownloadParams(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(fileName),
+	}, opts...)
+	if params.err != nil {
+		return params.err
+	}
+	input := params.input
+
 	file, err := os.Create(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to create file %q, %v", filePath, err)
+		return fmt.Errorf("failed to create file %q, %w", filePath, err)
 	}
 	defer file.Close()
 
-	downloader := manager.NewDownloader(a.s3Client)
-	_, err = downloader.Download(ctx, file,
-		&s3.GetObjectInput{
-			Bucket: aws.String(bucket),
-			Key:    aws.String(fileName),
-		})
+	var sums *objectChecksums
+	if input.ChecksumMode == types.ChecksumModeEnabled {
+		if sums, err = a.objectChecksums(ctx, input); err != nil {
+			return err
+		}
+		// Download the revision the checksums belong to.
+		if input.IfMatch == nil {
+			input.IfMatch = aws.String(sums.etag)
+		}
+	}
+
+	tracker := newProgressTracker(params.progress, "download", bucket, fileName, -1)
+	if a.params.ClientEncryption() != nil {
+		err = a.downloadDecrypted(ctx, file, input, tracker)
+	} else {
+		var w io.WriterAt = file
+		if tracker != nil {
+			w = &progressWriterAt{WriterAt: file, tracker: tracker}
+		}
+		if _, err = a.downloader.Download(ctx, w, input, a.downloaderOptions(params, tracker)); err != nil {
+			err = fmt.Errorf("failed to download file, %w", newOpError("GetObject", bucket, fileName, err))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if sums != nil {
+		if err := verifyFile(file, sums); err != nil {
+			return fmt.Errorf("downloaded file %q failed verification, %w", filePath, err)
+		}
+	}
+
+	tracker.finish()
+	return nil
+}
+
+// verifyFile checks the content written to file against sums. Parts are
+// downloaded out of order, so the file is read back once complete.
+func verifyFile(file *os.File, sums *objectChecksums) error {
+	verifier, err := newChecksumVerifier(sums)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(verifier, file); err != nil {
+		return err
+	}
+	return verifier.Verify()
+}
+
+// downloadDecrypted reads the object in a single request, since the segments
+// of an encrypted object have to be decrypted in order. Progress counts the
+// ciphertext read.
+func (a *AWSTools) downloadDecrypted(ctx context.Context, w io.Writer, input *s3.GetObjectInput, tracker *progressTracker) error {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	out, err := a.s3Client.GetObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to download file, %w", newOpError("GetObject", bucket, key, err))
+	}
+	defer out.Body.Close()
+
+	tracker.setTotal(aws.ToInt64(out.ContentLength))
+	out.Body = readCloser{newProgressReader(out.Body, tracker), out.Body}
+
+	body, err := a.plaintextBody(ctx, out)
 	if err != nil {
-		return fmt.Errorf("failed to download file, %v", err)
+		return fmt.Errorf("failed to decrypt object %q in bucket %q, %w", key, bucket, err)
+	}
+
+	if _, err := io.Copy(w, body); err != nil {
+		return fmt.Errorf("failed to download file, %w", err)
 	}
 
 	return nil
@@ -179,7 +312,7 @@ func (a *AWSTools) ListFilesInBucketWithContext(ctx context.Context, bucket stri
 
 	result, err := a.s3Client.ListObjectsV2(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("unable to list items in bucket %q, %v", bucket, err)
+		return nil, fmt.Errorf("unable to list items in bucket %q, %w", bucket, newOpError("ListObjectsV2", bucket, "", err))
 	}
 
 	return result.Contents, nil
@@ -192,7 +325,7 @@ func (a *AWSTools) ListBuckets() ([]types.Bucket, error) {
 func (a *AWSTools) ListBucketsWithContext(ctx context.Context) ([]types.Bucket, error) {
 	result, err := a.s3Client.ListBuckets(ctx, &s3.ListBucketsInput{})
 	if err != nil {
-		return nil, fmt.Errorf("unable to list buckets: %s", err)
+		return nil, fmt.Errorf("unable to list buckets: %w", newOpError("ListBuckets", "", "", err))
 	}
 
 	return result.Buckets, nil
@@ -208,7 +341,7 @@ func (a *AWSTools) DeleteFileInS3WithContext(ctx context.Context, bucket, fileNa
 		Key:    aws.String(fileName),
 	})
 	if err != nil {
-		return fmt.Errorf("unable to delete object %q from bucket %q, %v", fileName, bucket, err)
+		return fmt.Errorf("unable to delete object %q from bucket %q, %w", fileName, bucket, newOpError("DeleteObject", bucket, fileName, err))
 	}
 
 	return nil
@@ -219,6 +352,20 @@ func (a *AWSTools) ReadFileStreamFromS3(bucket, fileName string, cb CallBack) ch
 }
 
 func (a *AWSTools) ReadFileStreamFromS3WithContext(ctx context.Context, bucket, fileName string, cb CallBack) chan error {
+	return a.ReadFileStreamFromS3WithContextAndOptions(ctx, bucket, fileName, cb)
+}
+
+func (a *AWSTools) ReadFileStreamFromS3WithOptions(bucket, fileName string, cb CallBack, opts ...DownloadOption) chan error {
+	return a.ReadFileStreamFromS3WithContextAndOptions(context.Background(), bucket, fileName, cb, opts...)
+}
+
+func (a *AWSTools) ReadFileStreamFromS3WithContextAndOptions(ctx context.Context, bucket, fileName string, cb CallBack, opts ...DownloadOption) chan error {
+	params := newDownloadParams(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(fileName),
+	}, opts...)
+	input := params.input
+
 	errorChan := make(chan error, a.queueWorkers)
 	queueFS := make(chan string, a.params.BufferLimit())
 
@@ -239,17 +386,47 @@ func (a *AWSTools) ReadFileStreamFromS3WithContext(ctx context.Context, bucket,
 			wg.Done()
 		}()
 
-		resp, err := a.s3Client.GetObject(ctx, &s3.GetObjectInput{
-			Bucket: aws.String(bucket),
-			Key:    aws.String(fileName),
-		})
+		if params.err != nil {
+			errorChan <- params.err
+			return
+		}
+
+		var verifier *checksumVerifier
+		if input.ChecksumMode == types.ChecksumModeEnabled {
+			sums, err := a.objectChecksums(ctx, input)
+			if err == nil {
+				verifier, err = newChecksumVerifier(sums)
+			}
+			if err != nil {
+				errorChan <- fmt.Errorf("Failed to get checksums: %w", err)
+				return
+			}
+			if input.IfMatch == nil {
+				input.IfMatch = aws.String(sums.etag)
+			}
+		}
+
+		resp, err := a.s3Client.GetObject(ctx, input)
 		if err != nil {
-			errorChan <- fmt.Errorf("Failed to get file: %v", err)
+			errorChan <- fmt.Errorf("Failed to get file: %w", newOpError("GetObject", bucket, fileName, err))
 			return
 		}
 		defer resp.Body.Close()
 
-		reader := bufio.NewReader(resp.Body)
+		tracker := newProgressTracker(params.progress, "stream", bucket, fileName, aws.ToInt64(resp.ContentLength))
+		resp.Body = readCloser{newProgressReader(resp.Body, tracker), resp.Body}
+
+		body, err := a.plaintextBody(ctx, resp)
+		if err != nil {
+			errorChan <- fmt.Errorf("Failed to decrypt file: %w", err)
+			return
+		}
+
+		if verifier != nil {
+			body = io.TeeReader(body, verifier)
+		}
+
+		reader := bufio.NewReader(body)
 		fmt.Println("Starting to read lines from S3")
 
 		for {
@@ -263,12 +440,23 @@ func (a *AWSTools) ReadFileStreamFromS3WithContext(ctx context.Context, bucket,
 			}
 
 			if err != nil {
-				errorChan <- fmt.Errorf("Read line error: %v", err)
+				errorChan <- fmt.Errorf("Read line error: %w", err)
 				return
 			}
 
 			queueFS <- line
 		}
+
+		// Lines were already handed to the workers; a mismatch can only be
+		// reported once the whole object has been read.
+		if verifier != nil {
+			if err := verifier.Verify(); err != nil {
+				errorChan <- fmt.Errorf("Checksum error: %w", err)
+				return
+			}
+		}
+
+		tracker.finish()
 	}(wg)
 
 	go func() {
@@ -321,15 +509,8 @@ func (a *AWSTools) MoveFileInS3(bucket, source, dest string) error {
 }
 
 func (a *AWSTools) MoveFileInS3WithContext(ctx context.Context, bucket, source, dest string) error {
-	if err := a.CopyFileInS3WithContext(ctx, bucket, source, dest); err != nil {
-		return err
-	}
-
-	if err := a.DeleteFileInS3WithContext(ctx, bucket, source); err != nil {
-		return err
-	}
-
-	return nil
+	_, err := a.MoveObjectWithContext(ctx, bucket, source, bucket, dest)
+	return err
 }
 
 func (a *AWSTools) CopyFileInS3(bucket, source, dest string) error {
@@ -337,15 +518,6 @@ func (a *AWSTools) CopyFileInS3(bucket, source, dest string) error {
 }
 
 func (a *AWSTools) CopyFileInS3WithContext(ctx context.Context, bucket, source, dest string) error {
-	_, err := a.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
-		Bucket:     aws.String(bucket),
-		CopySource: aws.String(bucket + "/" + source),
-		Key:        aws.String(dest),
-	})
-
-	if err != nil {
-		return fmt.Errorf("unable to copy object %q from bucket %q to %q, %v", source, bucket, dest, err)
-	}
-
-	return nil
+	_, err := a.CopyObjectWithContext(ctx, bucket, source, bucket, dest)
+	return err
 }
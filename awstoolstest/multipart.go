@@ -0,0 +1,474 @@
+package awstoolstest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minPartSize is the minimum size of every part but the last, as enforced
+// by S3 when completing an upload.
+const minPartSize = 5 * 1024 * 1024
+
+type upload struct {
+	bucket    string
+	key       string
+	id        string
+	initiated time.Time
+	header    http.Header
+	tags      []tag
+	checksum  string // checksum header of the algorithm, if any
+	parts     map[int]*part
+}
+
+type part struct {
+	data         []byte
+	etag         string
+	checksum     string
+	lastModified time.Time
+}
+
+func (s *Server) upload(bucketName, key, id string) (*upload, error) {
+	if _, err := s.bucket(bucketName); err != nil {
+		return nil, err
+	}
+	up := s.uploads[id]
+	if up == nil || up.bucket != bucketName || up.key != key {
+		return nil, errNoSuchUpload(id)
+	}
+	return up, nil
+}
+
+// checksumHeader returns the checksum header of algorithm, e.g. CRC32C.
+func checksumHeader(algorithm string) (string, error) {
+	header := "x-amz-checksum-" + strings.ToLower(algorithm)
+	if _, ok := checksumHeaders[header]; !ok {
+		return "", errorf(http.StatusBadRequest, "InvalidRequest", "Unsupported checksum algorithm %q", algorithm)
+	}
+	return header, nil
+}
+
+func (s *Server) createMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	if _, err := s.bucket(bucketName); err != nil {
+		return err
+	}
+
+	up := &upload{
+		bucket:    bucketName,
+		key:       key,
+		initiated: now(),
+		header:    objectHeader(r),
+		parts:     make(map[int]*part),
+	}
+	var err error
+	if up.tags, err = parseTagging(r.Header.Get("x-amz-tagging")); err != nil {
+		return err
+	}
+	if algorithm := r.Header.Get("x-amz-checksum-algorithm"); algorithm != "" {
+		if up.checksum, err = checksumHeader(algorithm); err != nil {
+			return err
+		}
+		w.Header().Set("x-amz-checksum-algorithm", strings.ToUpper(algorithm))
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	up.id = hex.EncodeToString(id)
+	s.uploads[up.id] = up
+
+	writeXML(w, http.StatusOK, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string
+		Key      string
+		UploadId string
+	}{Bucket: bucketName, Key: key, UploadId: up.id})
+	return nil
+}
+
+func partNumber(r *http.Request) (int, error) {
+	n, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || n < 1 || n > 10000 {
+		return 0, errorf(http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000")
+	}
+	return n, nil
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, bucketName, key string, body []byte) error {
+	up, err := s.upload(bucketName, key, r.URL.Query().Get("uploadId"))
+	if err != nil {
+		return err
+	}
+	number, err := partNumber(r)
+	if err != nil {
+		return err
+	}
+	checksums, err := verifyBody(r, body)
+	if err != nil {
+		return err
+	}
+
+	sum := md5.Sum(body)
+	p := &part{data: body, etag: hex.EncodeToString(sum[:]), lastModified: now()}
+	if up.checksum != "" {
+		if p.checksum = checksums[up.checksum]; p.checksum == "" {
+			p.checksum = checksumOf(up.checksum, body)
+		}
+		w.Header().Set(up.checksum, p.checksum)
+	}
+	up.parts[number] = p
+
+	w.Header().Set("ETag", quote(p.etag))
+	return nil
+}
+
+func (s *Server) uploadPartCopy(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	up, err := s.upload(bucketName, key, r.URL.Query().Get("uploadId"))
+	if err != nil {
+		return err
+	}
+	number, err := partNumber(r)
+	if err != nil {
+		return err
+	}
+	src, _, _, err := s.copySource(r)
+	if err != nil {
+		return err
+	}
+
+	data := src.data
+	if header := r.Header.Get("x-amz-copy-source-range"); header != "" {
+		start, end, ok, err := parseRange(header, int64(len(data)))
+		if err != nil || !ok || end >= int64(len(src.data)) {
+			return errorf(http.StatusBadRequest, "InvalidArgument", "The x-amz-copy-source-range %q is not valid", header)
+		}
+		data = data[start : end+1]
+	}
+
+	data = bytes.Clone(data)
+	sum := md5.Sum(data)
+	p := &part{data: data, etag: hex.EncodeToString(sum[:]), lastModified: now()}
+	if up.checksum != "" {
+		p.checksum = checksumOf(up.checksum, data)
+	}
+	up.parts[number] = p
+
+	result := struct {
+		XMLName        xml.Name `xml:"CopyPartResult"`
+		ETag           string
+		LastModified   string
+		ChecksumCRC32  string `xml:",omitempty"`
+		ChecksumCRC32C string `xml:",omitempty"`
+		ChecksumSHA1   string `xml:",omitempty"`
+		ChecksumSHA256 string `xml:",omitempty"`
+	}{ETag: quote(p.etag), LastModified: timestamp(p.lastModified)}
+	xp := xmlPart{}
+	xp.setChecksum(up.checksum, p.checksum)
+	result.ChecksumCRC32, result.ChecksumCRC32C = xp.ChecksumCRC32, xp.ChecksumCRC32C
+	result.ChecksumSHA1, result.ChecksumSHA256 = xp.ChecksumSHA1, xp.ChecksumSHA256
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// xmlPart is a part in ListParts and CompleteMultipartUpload documents.
+type xmlPart struct {
+	PartNumber     int
+	LastModified   string `xml:",omitempty"`
+	ETag           string
+	Size           int64  `xml:",omitempty"`
+	ChecksumCRC32  string `xml:",omitempty"`
+	ChecksumCRC32C string `xml:",omitempty"`
+	ChecksumSHA1   string `xml:",omitempty"`
+	ChecksumSHA256 string `xml:",omitempty"`
+}
+
+// checksum returns the checksum of the element named by the checksum
+// header.
+func (p *xmlPart) checksum(header string) string {
+	switch checksumHeaders[header] {
+	case "ChecksumCRC32":
+		return p.ChecksumCRC32
+	case "ChecksumCRC32C":
+		return p.ChecksumCRC32C
+	case "ChecksumSHA1":
+		return p.ChecksumSHA1
+	case "ChecksumSHA256":
+		return p.ChecksumSHA256
+	}
+	return ""
+}
+
+func (p *xmlPart) setChecksum(header, value string) {
+	switch checksumHeaders[header] {
+	case "ChecksumCRC32":
+		p.ChecksumCRC32 = value
+	case "ChecksumCRC32C":
+		p.ChecksumCRC32C = value
+	case "ChecksumSHA1":
+		p.ChecksumSHA1 = value
+	case "ChecksumSHA256":
+		p.ChecksumSHA256 = value
+	}
+}
+
+func (s *Server) listParts(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	q := r.URL.Query()
+	up, err := s.upload(bucketName, key, q.Get("uploadId"))
+	if err != nil {
+		return err
+	}
+
+	maxParts := 1000
+	if v, err := strconv.Atoi(q.Get("max-parts")); err == nil && v >= 0 {
+		maxParts = min(v, 1000)
+	}
+	marker, _ := strconv.Atoi(q.Get("part-number-marker"))
+
+	numbers := make([]int, 0, len(up.parts))
+	for number := range up.parts {
+		if number > marker {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+
+	result := struct {
+		XMLName              xml.Name `xml:"ListPartsResult"`
+		Bucket               string
+		Key                  string
+		UploadId             string
+		PartNumberMarker     int
+		NextPartNumberMarker int `xml:",omitempty"`
+		MaxParts             int
+		IsTruncated          bool
+		StorageClass         string
+		ChecksumAlgorithm    string    `xml:",omitempty"`
+		Parts                []xmlPart `xml:"Part"`
+	}{
+		Bucket:           bucketName,
+		Key:              key,
+		UploadId:         up.id,
+		PartNumberMarker: marker,
+		MaxParts:         maxParts,
+		StorageClass:     "STANDARD",
+	}
+	if up.checksum != "" {
+		result.ChecksumAlgorithm = strings.ToUpper(strings.TrimPrefix(up.checksum, "x-amz-checksum-"))
+	}
+
+	if len(numbers) > maxParts {
+		numbers = numbers[:maxParts]
+		result.IsTruncated = true
+	}
+	for _, number := range numbers {
+		p := up.parts[number]
+		xp := xmlPart{
+			PartNumber:   number,
+			LastModified: timestamp(p.lastModified),
+			ETag:         quote(p.etag),
+			Size:         int64(len(p.data)),
+		}
+		xp.setChecksum(up.checksum, p.checksum)
+		result.Parts = append(result.Parts, xp)
+		result.NextPartNumberMarker = number
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (s *Server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, key string, body []byte) error {
+	up, err := s.upload(bucketName, key, r.URL.Query().Get("uploadId"))
+	if err != nil {
+		return err
+	}
+
+	var request struct {
+		Parts []xmlPart `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &request); err != nil {
+		return errorf(http.StatusBadRequest, "MalformedXML", "%v", err)
+	}
+	if len(request.Parts) == 0 {
+		return errorf(http.StatusBadRequest, "MalformedXML", "The XML you provided did not list any part")
+	}
+
+	var data bytes.Buffer
+	var etags, checksums []byte
+	var partSums []string
+	sizes := make([]int64, 0, len(request.Parts))
+	for i, xp := range request.Parts {
+		if i > 0 && xp.PartNumber <= request.Parts[i-1].PartNumber {
+			return errorf(http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order")
+		}
+		p := up.parts[xp.PartNumber]
+		if p == nil || strings.Trim(xp.ETag, `"`) != p.etag {
+			return errorf(http.StatusBadRequest, "InvalidPart", "Part %d could not be found or its ETag does not match", xp.PartNumber)
+		}
+		if want := xp.checksum(up.checksum); want != "" && want != p.checksum {
+			return errorf(http.StatusBadRequest, "InvalidPart", "The checksum of part %d does not match", xp.PartNumber)
+		}
+		if i < len(request.Parts)-1 && len(p.data) < minPartSize {
+			return errorf(http.StatusBadRequest, "EntityTooSmall", "Part %d is smaller than the minimum allowed size", xp.PartNumber)
+		}
+
+		data.Write(p.data)
+		sizes = append(sizes, int64(len(p.data)))
+		sum, _ := hex.DecodeString(p.etag)
+		etags = append(etags, sum...)
+		if up.checksum != "" {
+			raw, _ := base64.StdEncoding.DecodeString(p.checksum)
+			checksums = append(checksums, raw...)
+			partSums = append(partSums, p.checksum)
+		}
+	}
+
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	obj := newObject(data.Bytes(), up.header)
+	sum := md5.Sum(etags)
+	obj.etag = fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), len(request.Parts))
+	obj.tags = up.tags
+	obj.partSizes = sizes
+	if up.checksum != "" {
+		h := newChecksumHash(up.checksum)
+		h.Write(checksums)
+		obj.partSums = partSums
+		obj.checksums = map[string]string{
+			up.checksum: fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(h.Sum(nil)), len(request.Parts)),
+		}
+	}
+	b.objects[key] = obj
+	delete(s.uploads, up.id)
+
+	result := struct {
+		XMLName        xml.Name `xml:"CompleteMultipartUploadResult"`
+		Location       string
+		Bucket         string
+		Key            string
+		ETag           string
+		ChecksumCRC32  string `xml:",omitempty"`
+		ChecksumCRC32C string `xml:",omitempty"`
+		ChecksumSHA1   string `xml:",omitempty"`
+		ChecksumSHA256 string `xml:",omitempty"`
+	}{
+		Location: s.URL + "/" + bucketName + "/" + key,
+		Bucket:   bucketName,
+		Key:      key,
+		ETag:     quote(obj.etag),
+	}
+	if up.checksum != "" {
+		xp := xmlPart{}
+		xp.setChecksum(up.checksum, obj.checksums[up.checksum])
+		result.ChecksumCRC32, result.ChecksumCRC32C = xp.ChecksumCRC32, xp.ChecksumCRC32C
+		result.ChecksumSHA1, result.ChecksumSHA256 = xp.ChecksumSHA1, xp.ChecksumSHA256
+	}
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (s *Server) abortMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	up, err := s.upload(bucketName, key, r.URL.Query().Get("uploadId"))
+	if err != nil {
+		return err
+	}
+	delete(s.uploads, up.id)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) listMultipartUploads(w http.ResponseWriter, r *http.Request, bucketName string) error {
+	if _, err := s.bucket(bucketName); err != nil {
+		return err
+	}
+
+	q := r.URL.Query()
+	prefix, keyMarker, idMarker := q.Get("prefix"), q.Get("key-marker"), q.Get("upload-id-marker")
+	maxUploads := 1000
+	if v, err := strconv.Atoi(q.Get("max-uploads")); err == nil && v >= 0 {
+		maxUploads = min(v, 1000)
+	}
+
+	var all []*upload
+	for _, up := range s.uploads {
+		if up.bucket == bucketName && strings.HasPrefix(up.key, prefix) && up.key >= keyMarker {
+			all = append(all, up)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].key != all[j].key {
+			return all[i].key < all[j].key
+		}
+		if !all[i].initiated.Equal(all[j].initiated) {
+			return all[i].initiated.Before(all[j].initiated)
+		}
+		return all[i].id < all[j].id
+	})
+
+	// Uploads of the key marker are skipped up to the upload ID marker, or
+	// entirely without one.
+	var uploads []*upload
+	passed := false
+	for _, up := range all {
+		if up.key == keyMarker && keyMarker != "" && !passed {
+			passed = idMarker != "" && up.id == idMarker
+			continue
+		}
+		uploads = append(uploads, up)
+	}
+
+	type xmlUpload struct {
+		Key          string
+		UploadId     string
+		Initiated    string
+		StorageClass string
+	}
+	result := struct {
+		XMLName            xml.Name `xml:"ListMultipartUploadsResult"`
+		Bucket             string
+		KeyMarker          string
+		UploadIdMarker     string
+		NextKeyMarker      string `xml:",omitempty"`
+		NextUploadIdMarker string `xml:",omitempty"`
+		Prefix             string
+		MaxUploads         int
+		IsTruncated        bool
+		Uploads            []xmlUpload `xml:"Upload"`
+	}{
+		Bucket:         bucketName,
+		KeyMarker:      keyMarker,
+		UploadIdMarker: idMarker,
+		Prefix:         prefix,
+		MaxUploads:     maxUploads,
+	}
+
+	if len(uploads) > maxUploads {
+		uploads = uploads[:maxUploads]
+		result.IsTruncated = true
+	}
+	for _, up := range uploads {
+		result.Uploads = append(result.Uploads, xmlUpload{
+			Key:          up.key,
+			UploadId:     up.id,
+			Initiated:    timestamp(up.initiated),
+			StorageClass: "STANDARD",
+		})
+	}
+	if result.IsTruncated {
+		last := uploads[len(uploads)-1]
+		result.NextKeyMarker, result.NextUploadIdMarker = last.key, last.id
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
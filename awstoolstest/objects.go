@@ -0,0 +1,677 @@
+package awstoolstest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// checksumHeaders maps the flexible checksum headers to the XML elements
+// holding the same values.
+var checksumHeaders = map[string]string{
+	"x-amz-checksum-crc32":  "ChecksumCRC32",
+	"x-amz-checksum-crc32c": "ChecksumCRC32C",
+	"x-amz-checksum-sha1":   "ChecksumSHA1",
+	"x-amz-checksum-sha256": "ChecksumSHA256",
+}
+
+// storedHeaders are the request headers kept with an object and returned by
+// GetObject and HeadObject, besides the user metadata.
+var storedHeaders = []string{
+	"Content-Type", "Content-Encoding", "Content-Disposition", "Content-Language",
+	"Cache-Control", "Expires", "x-amz-storage-class", "x-amz-website-redirect-location",
+	"x-amz-server-side-encryption", "x-amz-server-side-encryption-aws-kms-key-id",
+	"x-amz-server-side-encryption-bucket-key-enabled",
+	"x-amz-server-side-encryption-customer-algorithm", "x-amz-server-side-encryption-customer-key-MD5",
+}
+
+func newChecksumHash(header string) hash.Hash {
+	switch header {
+	case "x-amz-checksum-crc32":
+		return crc32.NewIEEE()
+	case "x-amz-checksum-crc32c":
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case "x-amz-checksum-sha1":
+		return sha1.New()
+	default:
+		return sha256.New()
+	}
+}
+
+func checksumOf(header string, data []byte) string {
+	h := newChecksumHash(header)
+	h.Write(data)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// verifyBody checks the Content-MD5 and flexible checksum headers of a
+// request against body and returns the checksums to store.
+func verifyBody(r *http.Request, body []byte) (map[string]string, error) {
+	if want := r.Header.Get("Content-MD5"); want != "" {
+		sum := md5.Sum(body)
+		if base64.StdEncoding.EncodeToString(sum[:]) != want {
+			return nil, errorf(http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received")
+		}
+	}
+
+	var checksums map[string]string
+	for header := range checksumHeaders {
+		want := r.Header.Get(header)
+		if want == "" {
+			continue
+		}
+		if got := checksumOf(header, body); got != want {
+			return nil, errorf(http.StatusBadRequest, "BadDigest", "The %s you specified did not match the calculated checksum %s", header, got)
+		}
+		if checksums == nil {
+			checksums = make(map[string]string)
+		}
+		checksums[header] = want
+	}
+	return checksums, nil
+}
+
+// objectHeader returns the headers of the request stored with an object.
+func objectHeader(r *http.Request) http.Header {
+	header := http.Header{}
+	for _, name := range storedHeaders {
+		if v := r.Header.Get(name); v != "" {
+			header.Set(name, v)
+		}
+	}
+	for name, values := range r.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+			header[name] = values
+		}
+	}
+	return header
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, bucketName, key string, body []byte) error {
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	if r.Header.Get("If-None-Match") == "*" && b.objects[key] != nil {
+		return errorf(http.StatusPreconditionFailed, "PreconditionFailed", "The object %q already exists", key)
+	}
+	if match := r.Header.Get("If-Match"); match != "" {
+		if obj := b.objects[key]; obj == nil || !etagMatches(match, obj.etag) {
+			return errorf(http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+		}
+	}
+
+	checksums, err := verifyBody(r, body)
+	if err != nil {
+		return err
+	}
+	tags, err := parseTagging(r.Header.Get("x-amz-tagging"))
+	if err != nil {
+		return err
+	}
+
+	obj := newObject(body, objectHeader(r))
+	obj.checksums = checksums
+	obj.tags = tags
+	b.objects[key] = obj
+
+	w.Header().Set("ETag", quote(obj.etag))
+	writeChecksumHeaders(w, obj.checksums)
+	return nil
+}
+
+func writeChecksumHeaders(w http.ResponseWriter, checksums map[string]string) {
+	for header, value := range checksums {
+		w.Header().Set(header, value)
+	}
+}
+
+func etagMatches(condition, etag string) bool {
+	for _, candidate := range strings.Split(condition, ",") {
+		candidate = strings.Trim(strings.TrimSpace(candidate), `"`)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConditions evaluates conditional headers in the order S3 does. The
+// prefix selects the x-amz-copy-source-if-* headers of copies.
+func checkConditions(header http.Header, prefix string, obj *object) (status int) {
+	match := header.Get(prefix + "If-Match")
+	noneMatch := header.Get(prefix + "If-None-Match")
+	modified := obj.lastModified.Truncate(time.Second)
+
+	if match != "" {
+		if !etagMatches(match, obj.etag) {
+			return http.StatusPreconditionFailed
+		}
+	} else if t, err := http.ParseTime(header.Get(prefix + "If-Unmodified-Since")); err == nil && modified.After(t) {
+		return http.StatusPreconditionFailed
+	}
+
+	notModified := http.StatusNotModified
+	if prefix != "" {
+		// Copies report every failed condition as 412.
+		notModified = http.StatusPreconditionFailed
+	}
+	if noneMatch != "" {
+		if etagMatches(noneMatch, obj.etag) {
+			return notModified
+		}
+	} else if t, err := http.ParseTime(header.Get(prefix + "If-Modified-Since")); err == nil && !modified.After(t) {
+		return notModified
+	}
+	return 0
+}
+
+// parseRange parses a single byte range of an object of the given size.
+// ok is false when the header is absent or malformed, in which case the
+// whole object is returned, as S3 does.
+func parseRange(header string, size int64) (start, end int64, ok bool, err error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, nil
+	}
+	first, last, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, 0, false, nil
+	}
+
+	switch {
+	case first == "":
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false, nil
+		}
+		start, end = max(size-n, 0), size-1
+	default:
+		start, err = strconv.ParseInt(first, 10, 64)
+		if err != nil {
+			return 0, 0, false, nil
+		}
+		end = size - 1
+		if last != "" {
+			if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+				return 0, 0, false, nil
+			}
+			end = min(end, size-1)
+		}
+	}
+
+	if start >= size {
+		return 0, 0, false, errorf(http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range %q is not satisfiable", header)
+	}
+	return start, end, true, nil
+}
+
+// partRange returns the byte range of part number of a multipart object.
+// Objects uploaded in one request have a single part.
+func (obj *object) partRange(number int) (start, end int64, err error) {
+	sizes := obj.partSizes
+	if len(sizes) == 0 {
+		sizes = []int64{int64(len(obj.data))}
+	}
+	if number < 1 || number > len(sizes) {
+		return 0, 0, errorf(http.StatusRequestedRangeNotSatisfiable, "InvalidPartNumber", "The requested part number %d is not satisfiable", number)
+	}
+	for _, size := range sizes[:number-1] {
+		start += size
+	}
+	return start, start + sizes[number-1] - 1, nil
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	obj, err := s.object(bucketName, key)
+	if err != nil {
+		return err
+	}
+
+	header := w.Header()
+	header.Set("ETag", quote(obj.etag))
+	header.Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+	if status := checkConditions(r.Header, "", obj); status != 0 {
+		if status == http.StatusPreconditionFailed {
+			return errorf(status, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+		}
+		w.WriteHeader(status)
+		return nil
+	}
+
+	size := int64(len(obj.data))
+	start, end, partial := int64(0), size-1, false
+	if number := r.URL.Query().Get("partNumber"); number != "" {
+		n, _ := strconv.Atoi(number)
+		if start, end, err = obj.partRange(n); err != nil {
+			return err
+		}
+		partial = true
+		header.Set("x-amz-mp-parts-count", strconv.Itoa(max(len(obj.partSizes), 1)))
+	} else if size > 0 {
+		// S3 rejects ranges over empty objects; the SDK downloader relies on
+		// them returning the whole, empty, object instead, as MinIO does.
+		if start, end, partial, err = parseRange(r.Header.Get("Range"), size); err != nil {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			return err
+		}
+		if !partial {
+			start, end = 0, size-1
+		}
+	}
+
+	for name, values := range obj.header {
+		header[name] = values
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "binary/octet-stream")
+	}
+	if len(obj.tags) > 0 {
+		header.Set("x-amz-tagging-count", strconv.Itoa(len(obj.tags)))
+	}
+	if strings.EqualFold(r.Header.Get("x-amz-checksum-mode"), "ENABLED") && !partial {
+		writeChecksumHeaders(w, obj.checksums)
+	}
+	for name, values := range r.URL.Query() {
+		// response-content-type and friends override the stored headers.
+		if target, ok := strings.CutPrefix(name, "response-"); ok {
+			header.Set(target, values[0])
+		}
+	}
+
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("Content-Length", strconv.FormatInt(max(end-start+1, 0), 10))
+	status := http.StatusOK
+	if partial {
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		status = http.StatusPartialContent
+	}
+	w.WriteHeader(status)
+
+	if r.Method != http.MethodHead && size > 0 {
+		w.Write(obj.data[start : end+1])
+	}
+	return nil
+}
+
+func (s *Server) deleteObject(w http.ResponseWriter, bucketName, key string) error {
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	delete(b.objects, key)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) deleteObjects(w http.ResponseWriter, bucketName string, body []byte) error {
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	var request struct {
+		Quiet   bool
+		Objects []struct {
+			Key       string
+			VersionId string
+		} `xml:"Object"`
+	}
+	if err := xml.Unmarshal(body, &request); err != nil {
+		return errorf(http.StatusBadRequest, "MalformedXML", "%v", err)
+	}
+	if len(request.Objects) > 1000 {
+		return errorf(http.StatusBadRequest, "MalformedXML", "A delete request accepts at most 1000 keys")
+	}
+
+	type deleted struct {
+		Key string
+	}
+	type deleteError struct {
+		Key     string
+		Code    string
+		Message string
+	}
+	result := struct {
+		XMLName xml.Name      `xml:"DeleteResult"`
+		Deleted []deleted     `xml:"Deleted"`
+		Errors  []deleteError `xml:"Error"`
+	}{}
+
+	for _, o := range request.Objects {
+		if o.VersionId != "" && o.VersionId != "null" {
+			result.Errors = append(result.Errors, deleteError{Key: o.Key, Code: "NoSuchVersion", Message: "awstoolstest objects have no versions"})
+			continue
+		}
+		delete(b.objects, o.Key)
+		if !request.Quiet {
+			result.Deleted = append(result.Deleted, deleted{Key: o.Key})
+		}
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+// copySource resolves the x-amz-copy-source header of a copy request,
+// checking its conditional headers.
+func (s *Server) copySource(r *http.Request) (*object, string, string, error) {
+	source := r.Header.Get("x-amz-copy-source")
+	source, version, _ := strings.Cut(source, "?versionId=")
+	if version != "" && version != "null" {
+		return nil, "", "", errorf(http.StatusNotImplemented, "NotImplemented", "awstoolstest objects have no versions")
+	}
+
+	source, err := url.PathUnescape(strings.TrimPrefix(source, "/"))
+	if err != nil {
+		return nil, "", "", errorf(http.StatusBadRequest, "InvalidArgument", "Invalid copy source %q", source)
+	}
+	bucketName, key, ok := strings.Cut(source, "/")
+	if !ok || key == "" {
+		return nil, "", "", errorf(http.StatusBadRequest, "InvalidArgument", "Invalid copy source %q", source)
+	}
+
+	obj, err := s.object(bucketName, key)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if checkConditions(r.Header, "x-amz-copy-source-", obj) != 0 {
+		return nil, "", "", errorf(http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+	}
+	return obj, bucketName, key, nil
+}
+
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	src, srcBucket, srcKey, err := s.copySource(r)
+	if err != nil {
+		return err
+	}
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	replaceMetadata := strings.EqualFold(r.Header.Get("x-amz-metadata-directive"), "REPLACE")
+	replaceTags := strings.EqualFold(r.Header.Get("x-amz-tagging-directive"), "REPLACE")
+	if srcBucket == bucketName && srcKey == key && !replaceMetadata {
+		return errorf(http.StatusBadRequest, "InvalidRequest",
+			"This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata")
+	}
+
+	header := src.header.Clone()
+	if replaceMetadata {
+		header = objectHeader(r)
+	}
+	tags := src.tags
+	if replaceTags {
+		if tags, err = parseTagging(r.Header.Get("x-amz-tagging")); err != nil {
+			return err
+		}
+	}
+
+	obj := newObject(bytes.Clone(src.data), header)
+	obj.tags = tags
+	if len(src.checksums) > 0 && len(src.partSizes) == 0 {
+		obj.checksums = src.checksums
+	}
+	b.objects[key] = obj
+
+	writeXML(w, http.StatusOK, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string
+		LastModified string
+	}{ETag: quote(obj.etag), LastModified: timestamp(obj.lastModified)})
+	return nil
+}
+
+func (s *Server) listObjectsV2(w http.ResponseWriter, r *http.Request, bucketName string) error {
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	q := r.URL.Query()
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	maxKeys := 1000
+	if v := q.Get("max-keys"); v != "" {
+		if maxKeys, err = strconv.Atoi(v); err != nil || maxKeys < 0 {
+			return errorf(http.StatusBadRequest, "InvalidArgument", "Invalid max-keys %q", v)
+		}
+		maxKeys = min(maxKeys, 1000)
+	}
+
+	marker := q.Get("start-after")
+	if token := q.Get("continuation-token"); token != "" {
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect")
+		}
+		marker = string(decoded)
+	}
+
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int64
+		StorageClass string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	result := struct {
+		XMLName               xml.Name `xml:"ListBucketResult"`
+		Name                  string
+		Prefix                string
+		Delimiter             string `xml:",omitempty"`
+		StartAfter            string `xml:",omitempty"`
+		ContinuationToken     string `xml:",omitempty"`
+		NextContinuationToken string `xml:",omitempty"`
+		MaxKeys               int
+		KeyCount              int
+		IsTruncated           bool
+		Contents              []content
+		CommonPrefixes        []commonPrefix
+	}{
+		Name:              bucketName,
+		Prefix:            prefix,
+		Delimiter:         delimiter,
+		StartAfter:        q.Get("start-after"),
+		ContinuationToken: q.Get("continuation-token"),
+		MaxKeys:           maxKeys,
+	}
+
+	last := ""
+	for _, key := range b.sortedKeys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		entry, isPrefix := key, false
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entry, isPrefix = key[:len(prefix)+i+len(delimiter)], true
+			}
+		}
+		if entry <= marker || entry == last {
+			continue
+		}
+
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		last = entry
+		result.KeyCount++
+
+		if isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: entry})
+			continue
+		}
+		obj := b.objects[key]
+		storageClass := obj.header.Get("x-amz-storage-class")
+		if storageClass == "" {
+			storageClass = "STANDARD"
+		}
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			LastModified: timestamp(obj.lastModified),
+			ETag:         quote(obj.etag),
+			Size:         int64(len(obj.data)),
+			StorageClass: storageClass,
+		})
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(last))
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (s *Server) getObjectAttributes(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	obj, err := s.object(bucketName, key)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]bool{}
+	for _, name := range strings.Split(r.Header.Get("x-amz-object-attributes"), ",") {
+		attributes[strings.TrimSpace(name)] = true
+	}
+
+	type objectParts struct {
+		TotalPartsCount      int
+		PartNumberMarker     int
+		NextPartNumberMarker int
+		MaxParts             int
+		IsTruncated          bool
+		Parts                []xmlPart `xml:"Part"`
+	}
+	var result struct {
+		XMLName      xml.Name `xml:"GetObjectAttributesResponse"`
+		ETag         string   `xml:",omitempty"`
+		Checksum     *xmlPart `xml:",omitempty"`
+		ObjectParts  *objectParts
+		StorageClass string `xml:",omitempty"`
+		ObjectSize   int64  `xml:",omitempty"`
+	}
+
+	var checksumHeader string
+	for header := range obj.checksums {
+		checksumHeader = header
+	}
+	if attributes["ETag"] {
+		result.ETag = obj.etag
+	}
+	if attributes["Checksum"] && checksumHeader != "" {
+		result.Checksum = &xmlPart{}
+		result.Checksum.setChecksum(checksumHeader, obj.checksums[checksumHeader])
+	}
+	if attributes["StorageClass"] {
+		result.StorageClass = "STANDARD"
+	}
+	if attributes["ObjectSize"] {
+		result.ObjectSize = int64(len(obj.data))
+	}
+	if attributes["ObjectParts"] && len(obj.partSizes) > 0 {
+		maxParts := 1000
+		if v, err := strconv.Atoi(r.Header.Get("x-amz-max-parts")); err == nil && v >= 0 {
+			maxParts = min(v, 1000)
+		}
+		marker, _ := strconv.Atoi(r.Header.Get("x-amz-part-number-marker"))
+
+		parts := &objectParts{TotalPartsCount: len(obj.partSizes), PartNumberMarker: marker, MaxParts: maxParts}
+		for i, size := range obj.partSizes {
+			number := i + 1
+			if number <= marker {
+				continue
+			}
+			if len(parts.Parts) == maxParts {
+				parts.IsTruncated = true
+				break
+			}
+			xp := xmlPart{PartNumber: number, Size: size}
+			if len(obj.partSums) > i {
+				xp.setChecksum(checksumHeader, obj.partSums[i])
+			}
+			parts.Parts = append(parts.Parts, xp)
+			parts.NextPartNumberMarker = number
+		}
+		result.ObjectParts = parts
+	}
+
+	w.Header().Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+type tag struct {
+	Key   string
+	Value string
+}
+
+// parseTagging parses the URL-encoded tags of the x-amz-tagging header.
+func parseTagging(header string) ([]tag, error) {
+	if header == "" {
+		return nil, nil
+	}
+	values, err := url.ParseQuery(header)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "InvalidArgument", "Invalid tagging %q", header)
+	}
+	var tags []tag
+	for key := range values {
+		tags = append(tags, tag{Key: key, Value: values.Get(key)})
+	}
+	return tags, nil
+}
+
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  []tag    `xml:"TagSet>Tag"`
+}
+
+func (s *Server) getObjectTagging(w http.ResponseWriter, bucketName, key string) error {
+	obj, err := s.object(bucketName, key)
+	if err != nil {
+		return err
+	}
+	writeXML(w, http.StatusOK, tagging{TagSet: obj.tags})
+	return nil
+}
+
+func (s *Server) putObjectTagging(w http.ResponseWriter, bucketName, key string, body []byte) error {
+	obj, err := s.object(bucketName, key)
+	if err != nil {
+		return err
+	}
+	var t tagging
+	if err := xml.Unmarshal(body, &t); err != nil {
+		return errorf(http.StatusBadRequest, "MalformedXML", "%v", err)
+	}
+	obj.tags = t.TagSet
+	return nil
+}
+
+func (s *Server) deleteObjectTagging(w http.ResponseWriter, bucketName, key string) error {
+	obj, err := s.object(bucketName, key)
+	if err != nil {
+		return err
+	}
+	obj.tags = nil
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,522 @@
+// Package awstoolstest provides an in-memory fake of the S3 REST API for
+// testing code that uses awstools without a real S3 or MinIO.
+//
+// The fake speaks enough of the API for the operations of awstools: buckets,
+// PutObject, GetObject and HeadObject with ranges and conditional headers,
+// ListObjectsV2, DeleteObject(s), CopyObject, object tagging, the parts of
+// GetObjectAttributes and multipart uploads, including UploadPartCopy.
+// Requests are not authenticated and objects have no versions. Unsupported
+// operations fail with NotImplemented.
+//
+//	tools, srv := awstoolstest.New(t)
+//	srv.CreateBucket("bucket")
+//	err := tools.UploadFileToS3("bucket", "key", "testdata/file.txt")
+package awstoolstest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thiagozs/go-awstools"
+)
+
+// Server is a fake S3 endpoint holding its buckets in memory. It is safe for
+// concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	uploads   map[string]*upload
+	requestID int64
+}
+
+type bucket struct {
+	created time.Time
+	region  string
+	objects map[string]*object
+}
+
+type object struct {
+	data         []byte
+	etag         string // without quotes
+	lastModified time.Time
+	header       http.Header // content headers and user metadata
+	checksums    map[string]string
+	tags         []tag
+	partSizes    []int64  // sizes of the parts of multipart objects
+	partSums     []string // checksums of the parts, when uploaded with one
+}
+
+// New starts a Server, closed when the test ends, and returns an AWSTools
+// using it. opts are applied after the endpoint and credentials.
+func New(tb testing.TB, opts ...awstools.Options) (*awstools.AWSTools, *Server) {
+	tb.Helper()
+
+	srv := NewServer()
+	tb.Cleanup(srv.Close)
+
+	tools, err := awstools.NewAWSTools(append(srv.Options(), opts...)...)
+	if err != nil {
+		tb.Fatalf("failed to create AWSTools: %v", err)
+	}
+	return tools, srv
+}
+
+// NewServer starts a Server. The caller must Close it.
+func NewServer() *Server {
+	s := &Server{
+		buckets: make(map[string]*bucket),
+		uploads: make(map[string]*upload),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Options returns the awstools options pointing NewAWSTools at the server.
+func (s *Server) Options() []awstools.Options {
+	return []awstools.Options{
+		awstools.WithEndpoint(s.URL),
+		awstools.WithRegion("us-east-1"),
+		awstools.WithAccessKeyID("awstoolstest"),
+		awstools.WithSecretKey("awstoolstest"),
+	}
+}
+
+// CreateBucket creates the bucket name if it does not exist.
+func (s *Server) CreateBucket(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[name] == nil {
+		s.buckets[name] = newBucket("")
+	}
+}
+
+// PutObject stores data under key, creating the bucket if needed.
+func (s *Server) PutObject(bucketName, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buckets[bucketName]
+	if b == nil {
+		b = newBucket("")
+		s.buckets[bucketName] = b
+	}
+	b.objects[key] = newObject(data, http.Header{})
+}
+
+// Object returns a copy of the content of key, and whether it exists.
+func (s *Server) Object(bucketName, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buckets[bucketName]
+	if b == nil || b.objects[key] == nil {
+		return nil, false
+	}
+	return bytes.Clone(b.objects[key].data), true
+}
+
+// Keys returns the keys of bucket in lexicographic order.
+func (s *Server) Keys(bucketName string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buckets[bucketName]
+	if b == nil {
+		return nil
+	}
+	return b.sortedKeys()
+}
+
+// Uploads returns the number of incomplete multipart uploads.
+func (s *Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func newBucket(region string) *bucket {
+	return &bucket{created: now(), region: region, objects: make(map[string]*object)}
+}
+
+func newObject(data []byte, header http.Header) *object {
+	sum := md5.Sum(data)
+	return &object{
+		data:         data,
+		etag:         hex.EncodeToString(sum[:]),
+		lastModified: now(),
+		header:       header,
+	}
+}
+
+func (b *bucket) sortedKeys() []string {
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// now returns the current time with the millisecond precision of S3
+// listings.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// s3Error is an S3 error response.
+type s3Error struct {
+	status  int
+	Code    string
+	Message string
+}
+
+func (e *s3Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func errorf(status int, code, format string, args ...any) *s3Error {
+	return &s3Error{status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func errNoSuchBucket(name string) *s3Error {
+	return errorf(http.StatusNotFound, "NoSuchBucket", "The specified bucket %q does not exist", name)
+}
+
+func errNoSuchKey(key string) *s3Error {
+	return errorf(http.StatusNotFound, "NoSuchKey", "The specified key %q does not exist", key)
+}
+
+func errNoSuchUpload(id string) *s3Error {
+	return errorf(http.StatusNotFound, "NoSuchUpload", "The specified upload %q does not exist", id)
+}
+
+// supportedQuery lists the query parameters understood by the fake. Requests
+// with any other parameter, e.g. a subresource such as ?versioning, are
+// rejected with NotImplemented.
+var supportedQuery = map[string]bool{
+	"x-id": true, "list-type": true, "prefix": true, "delimiter": true, "max-keys": true,
+	"continuation-token": true, "start-after": true, "fetch-owner": true, "encoding-type": true,
+	"uploads": true, "uploadId": true, "partNumber": true, "max-parts": true, "part-number-marker": true,
+	"key-marker": true, "upload-id-marker": true, "max-uploads": true, "delete": true, "location": true,
+	"max-buckets": true, "tagging": true, "attributes": true,
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requestID++
+	requestID := strconv.FormatInt(s.requestID, 10)
+	s.mu.Unlock()
+	w.Header().Set("x-amz-request-id", requestID)
+
+	if err := s.serve(w, r); err != nil {
+		writeError(w, r, err, requestID)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) error {
+	for name := range r.URL.Query() {
+		if !supportedQuery[name] && !strings.HasPrefix(name, "response-") {
+			return errorf(http.StatusNotImplemented, "NotImplemented", "awstoolstest does not support ?%s", name)
+		}
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return errorf(http.StatusBadRequest, "IncompleteBody", "%v", err)
+	}
+
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case bucketName == "":
+		if r.Method == http.MethodGet {
+			return s.listBuckets(w, r)
+		}
+	case key == "":
+		switch {
+		case r.Method == http.MethodPut:
+			return s.createBucket(w, bucketName, body)
+		case r.Method == http.MethodDelete:
+			return s.deleteBucket(w, bucketName)
+		case r.Method == http.MethodHead:
+			_, err := s.bucket(bucketName)
+			return err
+		case r.Method == http.MethodGet && q.Has("location"):
+			return s.getBucketLocation(w, bucketName)
+		case r.Method == http.MethodGet && q.Has("uploads"):
+			return s.listMultipartUploads(w, r, bucketName)
+		case r.Method == http.MethodGet && q.Get("list-type") == "2":
+			return s.listObjectsV2(w, r, bucketName)
+		case r.Method == http.MethodPost && q.Has("delete"):
+			return s.deleteObjects(w, bucketName, body)
+		}
+	case q.Has("tagging"):
+		switch r.Method {
+		case http.MethodGet:
+			return s.getObjectTagging(w, bucketName, key)
+		case http.MethodPut:
+			return s.putObjectTagging(w, bucketName, key, body)
+		case http.MethodDelete:
+			return s.deleteObjectTagging(w, bucketName, key)
+		}
+	case q.Has("attributes"):
+		if r.Method == http.MethodGet {
+			return s.getObjectAttributes(w, r, bucketName, key)
+		}
+	case q.Has("uploadId"):
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("x-amz-copy-source") != "" {
+				return s.uploadPartCopy(w, r, bucketName, key)
+			}
+			return s.uploadPart(w, r, bucketName, key, body)
+		case http.MethodGet:
+			return s.listParts(w, r, bucketName, key)
+		case http.MethodPost:
+			return s.completeMultipartUpload(w, r, bucketName, key, body)
+		case http.MethodDelete:
+			return s.abortMultipartUpload(w, r, bucketName, key)
+		}
+	default:
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("x-amz-copy-source") != "" {
+				return s.copyObject(w, r, bucketName, key)
+			}
+			return s.putObject(w, r, bucketName, key, body)
+		case http.MethodGet, http.MethodHead:
+			return s.getObject(w, r, bucketName, key)
+		case http.MethodDelete:
+			return s.deleteObject(w, bucketName, key)
+		case http.MethodPost:
+			if q.Has("uploads") {
+				return s.createMultipartUpload(w, r, bucketName, key)
+			}
+		}
+	}
+
+	return errorf(http.StatusNotImplemented, "NotImplemented", "awstoolstest does not support %s %s", r.Method, r.URL.RequestURI())
+}
+
+func (s *Server) bucket(name string) (*bucket, error) {
+	b := s.buckets[name]
+	if b == nil {
+		return nil, errNoSuchBucket(name)
+	}
+	return b, nil
+}
+
+func (s *Server) object(bucketName, key string) (*object, error) {
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	obj := b.objects[key]
+	if obj == nil {
+		return nil, errNoSuchKey(key)
+	}
+	return obj, nil
+}
+
+// readBody reads the request body, decoding the aws-chunked encoding used
+// for streaming signatures and trailing checksums. Trailers are moved to the
+// request headers.
+func readBody(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("malformed aws-chunked body, %w", err)
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed aws-chunked body, %w", err)
+		}
+		if size == 0 {
+			break
+		}
+		if _, err := io.CopyN(&body, br, size); err != nil {
+			return nil, fmt.Errorf("malformed aws-chunked body, %w", err)
+		}
+		if _, err := br.ReadString('\n'); err != nil {
+			return nil, fmt.Errorf("malformed aws-chunked body, %w", err)
+		}
+	}
+
+	for {
+		line, err := br.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			r.Header.Set(name, strings.TrimSpace(value))
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	encoding := strings.TrimPrefix(strings.ReplaceAll(r.Header.Get("Content-Encoding"), "aws-chunked", ""), ",")
+	if encoding = strings.Trim(encoding, ", "); encoding == "" {
+		r.Header.Del("Content-Encoding")
+	} else {
+		r.Header.Set("Content-Encoding", encoding)
+	}
+	return body.Bytes(), nil
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error, requestID string) {
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) {
+		s3Err = errorf(http.StatusInternalServerError, "InternalError", "%v", err)
+	}
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(s3Err.status)
+		return
+	}
+	writeXML(w, s3Err.status, struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string
+		Message   string
+		Resource  string
+		RequestId string
+	}{Code: s3Err.Code, Message: s3Err.Message, Resource: r.URL.Path, RequestId: requestID})
+}
+
+func writeXML(w http.ResponseWriter, status int, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(xml.Header)+len(data)))
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	w.Write(data)
+}
+
+// timestamp formats t as in S3 XML documents.
+func timestamp(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func quote(etag string) string {
+	return `"` + etag + `"`
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+	prefix, token := q.Get("prefix"), q.Get("continuation-token")
+	maxBuckets := 10000
+	if v, err := strconv.Atoi(q.Get("max-buckets")); err == nil && v > 0 {
+		maxBuckets = v
+	}
+
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		if strings.HasPrefix(name, prefix) && name > token {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	type xmlBucket struct {
+		Name         string
+		CreationDate string
+	}
+	result := struct {
+		XMLName           xml.Name    `xml:"ListAllMyBucketsResult"`
+		Buckets           []xmlBucket `xml:"Buckets>Bucket"`
+		Prefix            string      `xml:",omitempty"`
+		ContinuationToken string      `xml:",omitempty"`
+	}{Prefix: prefix}
+
+	if len(names) > maxBuckets {
+		names = names[:maxBuckets]
+		result.ContinuationToken = names[len(names)-1]
+	}
+	for _, name := range names {
+		result.Buckets = append(result.Buckets, xmlBucket{Name: name, CreationDate: timestamp(s.buckets[name].created)})
+	}
+
+	writeXML(w, http.StatusOK, result)
+	return nil
+}
+
+func (s *Server) createBucket(w http.ResponseWriter, name string, body []byte) error {
+	if s.buckets[name] != nil {
+		return errorf(http.StatusConflict, "BucketAlreadyOwnedByYou", "The bucket %q already exists", name)
+	}
+
+	var config struct {
+		LocationConstraint string
+	}
+	if len(body) > 0 {
+		if err := xml.Unmarshal(body, &config); err != nil {
+			return errorf(http.StatusBadRequest, "MalformedXML", "%v", err)
+		}
+	}
+
+	s.buckets[name] = newBucket(config.LocationConstraint)
+	w.Header().Set("Location", "/"+name)
+	return nil
+}
+
+func (s *Server) deleteBucket(w http.ResponseWriter, name string) error {
+	b, err := s.bucket(name)
+	if err != nil {
+		return err
+	}
+	if len(b.objects) > 0 {
+		return errorf(http.StatusConflict, "BucketNotEmpty", "The bucket %q is not empty", name)
+	}
+	for _, up := range s.uploads {
+		if up.bucket == name {
+			return errorf(http.StatusConflict, "BucketNotEmpty", "The bucket %q has multipart uploads", name)
+		}
+	}
+
+	delete(s.buckets, name)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) getBucketLocation(w http.ResponseWriter, name string) error {
+	b, err := s.bucket(name)
+	if err != nil {
+		return err
+	}
+	writeXML(w, http.StatusOK, struct {
+		XMLName xml.Name `xml:"LocationConstraint"`
+		Region  string   `xml:",chardata"`
+	}{Region: b.region})
+	return nil
+}
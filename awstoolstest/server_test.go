@@ -0,0 +1,404 @@
+package awstoolstest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/thiagozs/go-awstools"
+)
+
+func writeTestFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testData returns size bytes that differ at every offset modulo 251.
+func testData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestBuckets(t *testing.T) {
+	tools, _ := New(t)
+
+	if err := tools.CreateBucket("first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tools.CreateBucket("second"); err != nil {
+		t.Fatal(err)
+	}
+
+	buckets, err := tools.ListBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 || *buckets[0].Name != "first" || *buckets[1].Name != "second" {
+		t.Errorf("Unexpected buckets: %v", buckets)
+	}
+
+	if exists, err := tools.BucketExists("first"); err != nil || !exists {
+		t.Errorf("Expected bucket to exist: %v %v", exists, err)
+	}
+	if err := tools.DeleteBucket("first"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := tools.BucketExists("first"); err != nil || exists {
+		t.Errorf("Expected bucket to be deleted: %v %v", exists, err)
+	}
+
+	if _, err := tools.StatObject("missing", "key"); !errors.Is(err, awstools.ErrNotFound) && !errors.Is(err, awstools.ErrBucketNotFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestUploadDownload(t *testing.T) {
+	tools, srv := New(t)
+	srv.CreateBucket("bucket")
+
+	path := writeTestFile(t, []byte("hello world"))
+	err := tools.UploadFileToS3WithOptions("bucket", "docs/hello.txt", path,
+		awstools.WithUploadContentType("text/plain"),
+		awstools.WithUploadMetadata(map[string]string{"Author": "ana"}),
+		awstools.WithUploadTags(map[string]string{"env": "test"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := tools.StatObject("bucket", "docs/hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 11 || info.ContentType != "text/plain" || info.Metadata["author"] != "ana" {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+	if info.ETag != "5eb63bbbe01eeed093cb22bb8f5acdc3" {
+		t.Errorf("Expected the MD5 of the content as ETag, got %s", info.ETag)
+	}
+
+	tags, err := tools.GetObjectTags("bucket", "docs/hello.txt")
+	if err != nil || tags["env"] != "test" {
+		t.Errorf("Unexpected tags: %v %v", tags, err)
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	if err := tools.DownloadFileFromS3("bucket", "docs/hello.txt", out); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "hello world" {
+		t.Errorf("Unexpected download: %q", data)
+	}
+
+	if _, err := tools.StatObject("bucket", "missing"); !errors.Is(err, awstools.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if exists, err := tools.ObjectExists("bucket", "missing"); err != nil || exists {
+		t.Errorf("Expected missing object: %v %v", exists, err)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	tools, srv := New(t, awstools.WithMultipartUploadPartSize(5*1024*1024))
+	srv.CreateBucket("bucket")
+
+	data := testData(12 * 1024 * 1024)
+	path := writeTestFile(t, data)
+	err := tools.UploadFileToS3WithOptions("bucket", "big", path,
+		awstools.WithUploadChecksum(types.ChecksumAlgorithmCrc32c))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := tools.StatObject("bucket", "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(info.ETag, "-3") {
+		t.Errorf("Expected a multipart ETag with 3 parts, got %s", info.ETag)
+	}
+	if stored, _ := srv.Object("bucket", "big"); !bytes.Equal(stored, data) {
+		t.Error("Stored object differs from the uploaded file")
+	}
+	if srv.Uploads() != 0 {
+		t.Errorf("Expected the upload to be completed, got %d pending", srv.Uploads())
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	err = tools.DownloadFileFromS3WithOptions("bucket", "big", out,
+		awstools.WithDownloadPartSize(5*1024*1024), awstools.WithDownloadChecksumValidation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloaded, _ := os.ReadFile(out); !bytes.Equal(downloaded, data) {
+		t.Error("Downloaded file differs from the uploaded file")
+	}
+}
+
+func TestRangeReads(t *testing.T) {
+	tools, srv := New(t)
+	srv.PutObject("bucket", "digits", []byte("0123456789"))
+
+	cases := []struct {
+		offset, length int64
+		want           string
+	}{
+		{2, 3, "234"},
+		{7, -1, "789"},
+		{-4, 0, "6789"},
+		{8, 10, "89"},
+	}
+	for _, c := range cases {
+		got, err := tools.GetObjectRange("bucket", "digits", c.offset, c.length)
+		if err != nil || string(got) != c.want {
+			t.Errorf("Range %d+%d: got %q %v, want %q", c.offset, c.length, got, err, c.want)
+		}
+	}
+	if _, err := tools.GetObjectRange("bucket", "digits", 20, 1); err == nil {
+		t.Error("Expected an error reading past the end")
+	}
+
+	obj, err := tools.OpenObject("bucket", "digits", awstools.WithDownloadReadAhead(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Close()
+
+	p := make([]byte, 3)
+	if n, err := obj.ReadAt(p, 5); n != 3 || err != nil || string(p) != "567" {
+		t.Errorf("Unexpected ReadAt: %d %v %q", n, err, p)
+	}
+
+	// Reads are pinned to the revision that was opened.
+	srv.PutObject("bucket", "digits", []byte("abcdefghij"))
+	if _, err := obj.ReadAt(p, 0); !errors.Is(err, awstools.ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed after a rewrite, got %v", err)
+	}
+}
+
+func TestListCopyDelete(t *testing.T) {
+	tools, srv := New(t,
+		awstools.WithMultipartCopyThreshold(6*1024*1024),
+		awstools.WithMultipartCopyPartSize(5*1024*1024))
+	for _, key := range []string{"logs/a", "logs/b", "logs/sub/c", "other"} {
+		srv.PutObject("bucket", key, []byte(key))
+	}
+
+	objects, err := tools.ListFilesInBucket("bucket")
+	if err != nil || len(objects) != 4 {
+		t.Fatalf("Unexpected listing: %v %v", objects, err)
+	}
+
+	if _, err := tools.CopyObject("bucket", "logs/a", "bucket", "copied/a",
+		awstools.WithCopyContentType("text/plain")); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := tools.StatObject("bucket", "copied/a"); err != nil || info.ContentType != "text/plain" {
+		t.Errorf("Unexpected copy: %+v %v", info, err)
+	}
+
+	big := testData(11 * 1024 * 1024)
+	srv.PutObject("bucket", "big", big)
+	if _, err := tools.CopyObject("bucket", "big", "bucket", "big-copy"); err != nil {
+		t.Fatal(err)
+	}
+	if copied, _ := srv.Object("bucket", "big-copy"); !bytes.Equal(copied, big) {
+		t.Error("Multipart copy differs from the source")
+	}
+
+	result, err := tools.DeletePrefix("bucket", "logs/")
+	if err != nil || len(result.Deleted) != 3 {
+		t.Fatalf("Unexpected delete: %+v %v", result, err)
+	}
+	if keys := strings.Join(srv.Keys("bucket"), ","); keys != "big,big-copy,copied/a,other" {
+		t.Errorf("Unexpected keys left: %s", keys)
+	}
+}
+
+func TestCleanupMultipartUploads(t *testing.T) {
+	tools, srv := New(t)
+	srv.CreateBucket("bucket")
+
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("awstoolstest", "awstoolstest", ""),
+	})
+	created, err := client.CreateMultipartUpload(context.Background(), &s3.CreateMultipartUploadInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("big"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.UploadPart(context.Background(), &s3.UploadPartInput{
+		Bucket:     aws.String("bucket"),
+		Key:        aws.String("big"),
+		UploadId:   created.UploadId,
+		PartNumber: aws.Int32(1),
+		Body:       bytes.NewReader(testData(1024)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uploads []awstools.MultipartUpload
+	for upload, err := range tools.ListMultipartUploads("bucket") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		uploads = append(uploads, upload)
+	}
+	if len(uploads) != 1 || uploads[0].Key != "big" {
+		t.Fatalf("Unexpected uploads: %+v", uploads)
+	}
+
+	result, err := tools.CleanupMultipartUploads("bucket", awstools.WithUploadsOlderThan(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Aborted) != 1 || result.BytesReclaimed != 1024 {
+		t.Errorf("Unexpected cleanup: %+v", result)
+	}
+	if srv.Uploads() != 0 {
+		t.Errorf("Expected no incomplete uploads, got %d", srv.Uploads())
+	}
+}
+
+func TestResumableTransfers(t *testing.T) {
+	tools, srv := New(t)
+	srv.CreateBucket("bucket")
+
+	data := testData(11 * 1024 * 1024)
+	path := writeTestFile(t, data)
+	if err := tools.UploadFileResumable("bucket", "big", path, "",
+		awstools.UploadTransfer{PartSize: 5 * 1024 * 1024}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := srv.Object("bucket", "big"); !bytes.Equal(stored, data) {
+		t.Error("Stored object differs from the uploaded file")
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	if err := tools.DownloadFileResumable("bucket", "big", out,
+		awstools.WithDownloadPartSize(1024*1024)); err != nil {
+		t.Fatal(err)
+	}
+	if downloaded, _ := os.ReadFile(out); !bytes.Equal(downloaded, data) {
+		t.Error("Downloaded file differs from the uploaded file")
+	}
+}
+
+func TestBucketFS(t *testing.T) {
+	tools, srv := New(t)
+	srv.PutObject("bucket", "site/index.html", []byte("<h1>home</h1>"))
+	srv.PutObject("bucket", "site/css/main.css", []byte("body {}"))
+	srv.PutObject("bucket", "site/img/logo.svg", []byte("<svg/>"))
+	srv.PutObject("bucket", "other/skip.txt", []byte("outside"))
+
+	if err := fstest.TestFS(tools.FS("bucket", "site"), "index.html", "css/main.css", "img/logo.svg"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	tools, srv := New(t)
+	srv.CreateBucket("bucket")
+	store := tools.Store("bucket", "data")
+
+	err := store.Put(ctx, "a.txt", strings.NewReader("hello"),
+		awstools.WithUploadContentType("text/plain"),
+		awstools.WithUploadMetadata(map[string]string{"Author": "ana"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Object("bucket", "data/a.txt"); !ok {
+		t.Fatal("Expected the object under the store prefix")
+	}
+
+	r, info, err := store.Get(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "hello" || info.Key != "a.txt" || info.ContentType != "text/plain" || info.Metadata["author"] != "ana" {
+		t.Errorf("Unexpected object: %q %+v", data, info)
+	}
+
+	if err := store.Copy(ctx, "a.txt", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for info, err := range store.List(ctx, "") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, info.Key)
+	}
+	if strings.Join(keys, ",") != "a.txt,b.txt" {
+		t.Errorf("Unexpected keys: %v", keys)
+	}
+
+	if err := store.Delete(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "a.txt"); err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+	if _, err := store.Stat(ctx, "a.txt"); !errors.Is(err, awstools.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Stat, got %v", err)
+	}
+	if _, _, err := store.Get(ctx, "a.txt"); !errors.Is(err, awstools.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Get, got %v", err)
+	}
+	if err := store.Copy(ctx, "a.txt", "c.txt"); !errors.Is(err, awstools.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Copy, got %v", err)
+	}
+}
+
+func TestNotImplemented(t *testing.T) {
+	tools, srv := New(t)
+	srv.CreateBucket("bucket")
+
+	if _, err := tools.GetBucketVersioning("bucket"); err == nil {
+		t.Error("Expected unsupported operations to fail")
+	}
+}
+
+func TestReadBodyAWSChunked(t *testing.T) {
+	body := "5;chunk-signature=abc\r\nhello\r\n6\r\n world\r\n0\r\nx-amz-checksum-crc32:DUoRhQ==\r\n\r\n"
+	r := httptest.NewRequest(http.MethodPut, "/bucket/key", strings.NewReader(body))
+	r.Header.Set("Content-Encoding", "aws-chunked,gzip")
+
+	data, err := readBody(r)
+	if err != nil || string(data) != "hello world" {
+		t.Fatalf("Unexpected body: %q %v", data, err)
+	}
+	if r.Header.Get("x-amz-checksum-crc32") != "DUoRhQ==" {
+		t.Error("Expected the trailing checksum to be moved to the headers")
+	}
+	if r.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected aws-chunked to be removed from Content-Encoding, got %q", r.Header.Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,443 @@
+package awstools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// BucketFS is a read-only fs.FS over the objects of a bucket under a prefix.
+// Keys are split on "/": the common prefixes returned by a delimited listing
+// are directories and the objects are files. Directories exist as long as
+// some object lies under them.
+//
+// BucketFS implements fs.ReadDirFS, fs.StatFS, fs.ReadFileFS and fs.SubFS, so
+// it can be used with fs.WalkDir, template.ParseFS or http.FS. Opened files
+// are ObjectReaders and support Seek and ReadAt.
+//
+// With client-side encryption configured, sizes are those of the plaintext,
+// which ReadDir reads from the metadata of every file it lists.
+type BucketFS struct {
+	a      *AWSTools
+	ctx    context.Context
+	bucket string
+	prefix string // empty or ending in "/"
+}
+
+var (
+	_ fs.ReadDirFS  = (*BucketFS)(nil)
+	_ fs.StatFS     = (*BucketFS)(nil)
+	_ fs.ReadFileFS = (*BucketFS)(nil)
+	_ fs.SubFS      = (*BucketFS)(nil)
+)
+
+func (a *AWSTools) FS(bucket, prefix string) *BucketFS {
+	return a.FSWithContext(context.Background(), bucket, prefix)
+}
+
+// FSWithContext returns the file system rooted at prefix in bucket. ctx is
+// used by every request made through it.
+func (a *AWSTools) FSWithContext(ctx context.Context, bucket, prefix string) *BucketFS {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &BucketFS{a: a, ctx: ctx, bucket: bucket, prefix: prefix}
+}
+
+// key returns the object key of a valid fs path.
+func (f *BucketFS) key(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name
+}
+
+// dirPrefix returns the listing prefix of the directory name.
+func (f *BucketFS) dirPrefix(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name + "/"
+}
+
+func (f *BucketFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		input := &s3.GetObjectInput{Bucket: aws.String(f.bucket), Key: aws.String(f.key(name))}
+		info, err := f.stat(input)
+		if err == nil {
+			if isEncrypted(info.Metadata) {
+				// Encrypted objects cannot be read by range; decrypt them whole.
+				data, err := f.ReadFile(name)
+				if err != nil {
+					return nil, err
+				}
+				return &memFile{Reader: bytes.NewReader(data), info: newFileInfo(name, info, int64(len(data)))}, nil
+			}
+			if err := f.a.checkEnvelope(info.Metadata); err != nil {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			return &objectFile{ObjectReader: f.a.newObjectReader(f.ctx, input, info, 0), name: name}, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fsError(err)}
+		}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &dirFile{info: dirInfo(name), entries: entries}, nil
+}
+
+func (f *BucketFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		info, err := f.stat(&s3.GetObjectInput{Bucket: aws.String(f.bucket), Key: aws.String(f.key(name))})
+		if err == nil {
+			return newFileInfo(name, info, plaintextSize(info)), nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: fsError(err)}
+		}
+
+		if exists, err := f.dirExists(name); err != nil || !exists {
+			if err == nil {
+				err = fs.ErrNotExist
+			}
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+	}
+
+	return dirInfo(name), nil
+}
+
+func (f *BucketFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+// ReadFile reads the whole object, decrypting it when it was uploaded with
+// client-side encryption.
+func (f *BucketFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	key := f.key(name)
+	out, err := f.a.s3Client.GetObject(f.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		err = fsError(newOpError("GetObject", f.bucket, key, err))
+		if errors.Is(err, fs.ErrNotExist) {
+			if exists, _ := f.dirExists(name); exists {
+				err = errors.New("is a directory")
+			}
+		}
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	defer out.Body.Close()
+
+	body, err := f.a.plaintextBody(f.ctx, out)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+func (f *BucketFS) Sub(dir string) (fs.FS, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: fs.ErrInvalid}
+	}
+	if dir == "." {
+		return f, nil
+	}
+	return &BucketFS{a: f.a, ctx: f.ctx, bucket: f.bucket, prefix: f.dirPrefix(dir)}, nil
+}
+
+func (f *BucketFS) stat(input *s3.GetObjectInput) (*ObjectInfo, error) {
+	return f.a.statObject(f.ctx, &s3.HeadObjectInput{Bucket: input.Bucket, Key: input.Key})
+}
+
+// dirExists reports whether any object lies under the directory name.
+func (f *BucketFS) dirExists(name string) (bool, error) {
+	out, err := f.a.s3Client.ListObjectsV2(f.ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(f.bucket),
+		Prefix:  aws.String(f.dirPrefix(name)),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return false, fsError(newOpError("ListObjectsV2", f.bucket, f.dirPrefix(name), err))
+	}
+	return len(out.Contents) > 0, nil
+}
+
+// readDir lists the directory name with a delimited listing, sorted by name.
+// The root of the file system always exists, even when empty.
+func (f *BucketFS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := f.dirPrefix(name)
+	paginator := s3.NewListObjectsV2Paginator(f.a.s3Client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(f.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+
+	var entries []fs.DirEntry
+	found := false
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(f.ctx)
+		if err != nil {
+			return nil, fsError(newOpError("ListObjectsV2", f.bucket, prefix, err))
+		}
+
+		for _, common := range page.CommonPrefixes {
+			found = true
+			dir := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(common.Prefix), prefix), "/")
+			if fs.ValidPath(dir) {
+				entries = append(entries, fs.FileInfoToDirEntry(dirInfo(dir)))
+			}
+		}
+		found = found || len(page.Contents) > 0
+		// Skip the zero-byte markers some tools create for directories.
+		files := slices.DeleteFunc(page.Contents, func(object types.Object) bool {
+			file := strings.TrimPrefix(aws.ToString(object.Key), prefix)
+			return file == "" || !fs.ValidPath(file)
+		})
+		sizes, err := f.a.listedSizes(f.ctx, f.bucket, files)
+		if err != nil {
+			return nil, fsError(err)
+		}
+		for i, object := range files {
+			if sizes[i] < 0 {
+				continue // deleted since the listing
+			}
+			entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{
+				name: strings.TrimPrefix(aws.ToString(object.Key), prefix),
+				size: sizes[i],
+				// HEAD reports whole seconds; match it so ReadDir and Stat agree.
+				modTime: aws.ToTime(object.LastModified).Truncate(time.Second),
+			}))
+		}
+	}
+
+	if !found && name != "." {
+		return nil, fs.ErrNotExist
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// fsError maps the errors of S3 requests onto the fs sentinel errors.
+func fsError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrBucketNotFound):
+		return fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+	case errors.Is(err, ErrAccessDenied):
+		return fmt.Errorf("%w: %w", fs.ErrPermission, err)
+	default:
+		return err
+	}
+}
+
+// plaintextSize returns the size of the object content, which for
+// client-side encrypted objects is recorded in the envelope. Envelopes of
+// bodies of unknown size lack it; the size then follows from the segment
+// layout, where every segment but the last holds cseSegmentSize bytes.
+func plaintextSize(info *ObjectInfo) int64 {
+	if size, ok := info.Metadata[metaCSESize]; ok {
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil {
+			return n
+		}
+	}
+	if !isEncrypted(info.Metadata) {
+		return info.Size
+	}
+	segments := max(1, (info.Size+cseSegmentSize+cseTagSize-1)/(cseSegmentSize+cseTagSize))
+	return max(0, info.Size-segments*cseTagSize)
+}
+
+// listedSizes returns the plaintext sizes of a page of listed objects, with
+// -1 for the objects deleted since the listing. Listings carry no metadata,
+// so with client-side encryption configured the objects large enough to be
+// encrypted are stated, on a pool of queueWorkers, to read the size from
+// their envelope. The first failure cancels the remaining requests.
+func (a *AWSTools) listedSizes(ctx context.Context, bucket string, objects []types.Object) ([]int64, error) {
+	sizes := make([]int64, len(objects))
+	var pending []int
+	for i, object := range objects {
+		sizes[i] = aws.ToInt64(object.Size)
+		if a.params.ClientEncryption() != nil && sizes[i] >= cseTagSize {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return sizes, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+	mu := &sync.Mutex{}
+	var firstErr error
+
+	wg := &sync.WaitGroup{}
+	for range min(a.queueWorkers, len(pending)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				info, err := a.StatObjectWithContext(ctx, bucket, aws.ToString(objects[i].Key))
+				switch {
+				case errors.Is(err, ErrNotFound):
+					sizes[i] = -1
+				case err != nil:
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+				default:
+					sizes[i] = plaintextSize(info)
+				}
+			}
+		}()
+	}
+
+	for _, i := range pending {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	// Only the caller can have cancelled ctx without a failure.
+	return sizes, ctx.Err()
+}
+
+// fileInfo describes an object or a directory of a BucketFS.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+	object  *ObjectInfo
+}
+
+func newFileInfo(name string, info *ObjectInfo, size int64) *fileInfo {
+	return &fileInfo{name: path.Base(name), size: size, modTime: info.LastModified, object: info}
+}
+
+func dirInfo(name string) *fileInfo {
+	return &fileInfo{name: path.Base(name), dir: true}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// Sys returns the *ObjectInfo of files opened or stated by name, and nil
+// otherwise.
+func (fi *fileInfo) Sys() any {
+	if fi.object == nil {
+		return nil
+	}
+	return fi.object
+}
+
+// objectFile is a file of a BucketFS read by range.
+type objectFile struct {
+	*ObjectReader
+	name string
+}
+
+func (f *objectFile) Stat() (fs.FileInfo, error) {
+	return newFileInfo(f.name, f.info, f.info.Size), nil
+}
+
+// memFile is a file of a BucketFS read whole into memory.
+type memFile struct {
+	*bytes.Reader
+	info *fileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// dirFile is an opened directory of a BucketFS.
+type dirFile struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,173 @@
+package awstools_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+func TestBucketFSConformance(t *testing.T) {
+	files := map[string]string{
+		"index.html":   "<h1>home</h1>",
+		"css/main.css": strings.Repeat("body {}\n", 10000),
+		"img/logo.svg": "<svg/>",
+		"empty.txt":    "",
+	}
+
+	tests := []struct {
+		name  string
+		tools func(t *testing.T) *awstools.AWSTools
+	}{
+		{
+			name: "plain",
+			tools: func(t *testing.T) *awstools.AWSTools {
+				tools, srv := awstoolstest.New(t)
+				srv.CreateBucket("bucket")
+				return tools
+			},
+		},
+		{
+			name: "client encryption",
+			tools: func(t *testing.T) *awstools.AWSTools {
+				tools, _ := newEncryptedTestTools(t)
+				return tools
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools := tt.tools(t)
+			store := tools.Store("bucket", "site")
+			for name, content := range files {
+				if err := store.Put(context.Background(), name, strings.NewReader(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fsys := tools.FS("bucket", "site")
+			if err := fstest.TestFS(fsys, "index.html", "css/main.css", "img/logo.svg", "empty.txt"); err != nil {
+				t.Fatal(err)
+			}
+
+			err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+				if err != nil || entry.IsDir() {
+					return err
+				}
+				info, err := entry.Info()
+				if err != nil {
+					return err
+				}
+				if want := int64(len(files[name])); info.Size() != want {
+					t.Errorf("%s: expected size %d, got %d", name, want, info.Size())
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// headRecorder proxies a Server, holding every HEAD request for a moment
+// and recording how many of them were in flight at once.
+type headRecorder struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	total    int
+}
+
+func (r *headRecorder) enter() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight++
+	r.total++
+	r.peak = max(r.peak, r.inFlight)
+}
+
+func (r *headRecorder) leave() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight--
+}
+
+// newListingTestTools returns client-side encrypted tools with the given
+// number of workers, talking to srv through a headRecorder.
+func newListingTestTools(t *testing.T, workers int) (*awstools.AWSTools, *awstoolstest.Server, *headRecorder) {
+	t.Helper()
+	srv := awstoolstest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.CreateBucket("bucket")
+
+	rec := &headRecorder{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			rec.enter()
+			defer rec.leave()
+			time.Sleep(20 * time.Millisecond)
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	wrapper, err := awstools.NewLocalKeyWrapper(bytes.Repeat([]byte{0x11}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools, err := awstools.NewAWSTools(append(srv.Options(),
+		awstools.WithEndpoint(proxy.URL),
+		awstools.WithClientEncryption(wrapper),
+		awstools.WithAmountWorkersRLS(workers))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tools, srv, rec
+}
+
+func TestBucketFSReadDirStatsInParallel(t *testing.T) {
+	tools, _, rec := newListingTestTools(t, 4)
+	store := tools.Store("bucket", "")
+	for i := range 12 {
+		content := strings.Repeat("x", i*10)
+		if err := store.Put(context.Background(), fmt.Sprintf("file-%02d", i), strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec.total = 0
+
+	entries, err := fs.ReadDir(tools.FS("bucket", ""), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 12 {
+		t.Fatalf("Expected 12 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := int64(i * 10); info.Size() != want {
+			t.Errorf("%s: expected size %d, got %d", entry.Name(), want, info.Size())
+		}
+	}
+	if rec.total != 12 {
+		t.Errorf("Expected 12 HEAD requests, got %d", rec.total)
+	}
+	if rec.peak < 2 || rec.peak > 4 {
+		t.Errorf("Expected between 2 and 4 HEAD requests in flight, got %d", rec.peak)
+	}
+}
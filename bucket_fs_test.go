@@ -0,0 +1,78 @@
+package awstools
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+func TestBucketFSPaths(t *testing.T) {
+	a := &AWSTools{}
+	fsys := a.FS("bucket", "site")
+
+	if got := fsys.key("css/main.css"); got != "site/css/main.css" {
+		t.Errorf("Unexpected key: %s", got)
+	}
+	if got := fsys.dirPrefix("."); got != "site/" {
+		t.Errorf("Unexpected root prefix: %s", got)
+	}
+
+	sub, err := fsys.Sub("css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sub.(*BucketFS).key("main.css"); got != "site/css/main.css" {
+		t.Errorf("Unexpected key in sub file system: %s", got)
+	}
+
+	if _, err := fsys.Sub("../etc"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Expected fs.ErrInvalid for an invalid path, got %v", err)
+	}
+	if _, err := fsys.Open("/abs"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("Expected fs.ErrInvalid for an absolute path, got %v", err)
+	}
+
+	if root := a.FS("bucket", ""); root.key("a.txt") != "a.txt" {
+		t.Error("Expected keys at the bucket root without a prefix")
+	}
+}
+
+func TestBucketFSDirFile(t *testing.T) {
+	d := &dirFile{info: dirInfo("dir"), entries: []fs.DirEntry{
+		fs.FileInfoToDirEntry(&fileInfo{name: "a"}),
+		fs.FileInfoToDirEntry(dirInfo("b")),
+		fs.FileInfoToDirEntry(&fileInfo{name: "c"}),
+	}}
+
+	if stat, _ := d.Stat(); !stat.IsDir() || stat.Mode()&fs.ModeDir == 0 {
+		t.Error("Expected a directory")
+	}
+	if _, err := d.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected reading a directory to fail")
+	}
+
+	first, err := d.ReadDir(2)
+	if err != nil || len(first) != 2 || first[1].Name() != "b" || !first[1].IsDir() {
+		t.Fatalf("Unexpected first batch: %v %v", first, err)
+	}
+	rest, err := d.ReadDir(2)
+	if err != nil || len(rest) != 1 {
+		t.Fatalf("Unexpected second batch: %v %v", rest, err)
+	}
+	if _, err := d.ReadDir(2); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end, got %v", err)
+	}
+}
+
+func TestFSError(t *testing.T) {
+	notFound := &OpError{Op: "HeadObject", Err: errors.New("not found"), kind: ErrNotFound}
+	if err := fsError(notFound); !errors.Is(err, fs.ErrNotExist) || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected both fs.ErrNotExist and ErrNotFound, got %v", err)
+	}
+
+	denied := &OpError{Op: "GetObject", Err: errors.New("denied"), kind: ErrAccessDenied}
+	if err := fsError(denied); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Expected fs.ErrPermission, got %v", err)
+	}
+}
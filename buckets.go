@@ -0,0 +1,350 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// BucketInfo describes a bucket together with the region it lives in.
+type BucketInfo struct {
+	Name         string
+	Region       string
+	CreationDate time.Time
+}
+
+// ListBucketsOption customizes a ListBucketsInfo call.
+type ListBucketsOption func(*listBucketsParams)
+
+type listBucketsParams struct {
+	prefix        string
+	pageSize      int32
+	resolveRegion bool
+}
+
+func newListBucketsParams(opts ...ListBucketsOption) *listBucketsParams {
+	p := &listBucketsParams{
+		pageSize:      1000,
+		resolveRegion: true,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// WithBucketPrefix only returns buckets whose name starts with prefix.
+func WithBucketPrefix(prefix string) ListBucketsOption {
+	return func(p *listBucketsParams) {
+		p.prefix = prefix
+	}
+}
+
+// WithBucketPageSize sets the number of buckets requested per page.
+func WithBucketPageSize(size int32) ListBucketsOption {
+	return func(p *listBucketsParams) {
+		if size > 0 {
+			p.pageSize = size
+		}
+	}
+}
+
+// WithBucketRegionLookup enables or disables the GetBucketLocation call
+// made for every listed bucket. It is enabled by default.
+func WithBucketRegionLookup(enabled bool) ListBucketsOption {
+	return func(p *listBucketsParams) {
+		p.resolveRegion = enabled
+	}
+}
+
+func (a *AWSTools) ListBucketsInfo(opts ...ListBucketsOption) ([]BucketInfo, error) {
+	return a.ListBucketsInfoWithContext(context.Background(), opts...)
+}
+
+func (a *AWSTools) ListBucketsInfoWithContext(ctx context.Context, opts ...ListBucketsOption) ([]BucketInfo, error) {
+	params := newListBucketsParams(opts...)
+
+	paginator := s3.NewListBucketsPaginator(a.s3Client, &s3.ListBucketsInput{
+		MaxBuckets: aws.Int32(params.pageSize),
+	})
+
+	var buckets []BucketInfo
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list buckets: %w", newOpError("ListBuckets", "", "", err))
+		}
+
+		for _, b := range page.Buckets {
+			name := aws.ToString(b.Name)
+			if !strings.HasPrefix(name, params.prefix) {
+				continue
+			}
+			buckets = append(buckets, BucketInfo{
+				Name:         name,
+				CreationDate: aws.ToTime(b.CreationDate),
+			})
+		}
+	}
+
+	if !params.resolveRegion || len(buckets) == 0 {
+		return buckets, nil
+	}
+
+	if err := a.resolveBucketRegions(ctx, buckets); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// resolveBucketRegions fills the Region field of every bucket, issuing the
+// GetBucketLocation calls with at most queueWorkers requests in flight.
+func (a *AWSTools) resolveBucketRegions(ctx context.Context, buckets []BucketInfo) error {
+	sem := make(chan struct{}, a.queueWorkers)
+	errs := make([]error, len(buckets))
+	wg := &sync.WaitGroup{}
+
+	for i := range buckets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			region, err := a.GetBucketRegionWithContext(ctx, buckets[i].Name)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			buckets[i].Region = region
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *AWSTools) GetBucketRegion(bucket string) (string, error) {
+	return a.GetBucketRegionWithContext(context.Background(), bucket)
+}
+
+func (a *AWSTools) GetBucketRegionWithContext(ctx context.Context, bucket string) (string, error) {
+	result, err := a.s3Client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to get location of bucket %q, %w", bucket, newOpError("GetBucketLocation", bucket, "", err))
+	}
+
+	return normalizeBucketRegion(result.LocationConstraint), nil
+}
+
+// normalizeBucketRegion maps a LocationConstraint to a region name. S3
+// reports buckets in us-east-1 with an empty constraint and legacy
+// eu-west-1 buckets as "EU".
+func normalizeBucketRegion(constraint types.BucketLocationConstraint) string {
+	switch constraint {
+	case "":
+		return "us-east-1"
+	case types.BucketLocationConstraintEu:
+		return "eu-west-1"
+	default:
+		return string(constraint)
+	}
+}
+
+// CreateBucketOption allows customizing the S3 CreateBucketInput before the
+// bucket is created.
+type CreateBucketOption func(input *s3.CreateBucketInput)
+
+// WithBucketRegion sets the location constraint of the new bucket. When not
+// given, the region configured on AWSTools is used.
+func WithBucketRegion(region string) CreateBucketOption {
+	return func(input *s3.CreateBucketInput) {
+		input.CreateBucketConfiguration = bucketConfiguration(region)
+	}
+}
+
+// WithBucketObjectLock enables S3 Object Lock on the new bucket, which also
+// turns on versioning.
+func WithBucketObjectLock(enabled bool) CreateBucketOption {
+	return func(input *s3.CreateBucketInput) {
+		input.ObjectLockEnabledForBucket = aws.Bool(enabled)
+	}
+}
+
+// WithBucketObjectOwnership sets the object ownership controls of the new bucket.
+func WithBucketObjectOwnership(ownership types.ObjectOwnership) CreateBucketOption {
+	return func(input *s3.CreateBucketInput) {
+		input.ObjectOwnership = ownership
+	}
+}
+
+// WithBucketACL applies a canned ACL to the new bucket.
+func WithBucketACL(acl types.BucketCannedACL) CreateBucketOption {
+	return func(input *s3.CreateBucketInput) {
+		input.ACL = acl
+	}
+}
+
+// bucketConfiguration returns the location constraint for region. S3 rejects
+// an explicit us-east-1 constraint, so no configuration is sent for it.
+func bucketConfiguration(region string) *types.CreateBucketConfiguration {
+	if region == "" || region == "us-east-1" {
+		return nil
+	}
+	return &types.CreateBucketConfiguration{
+		LocationConstraint: types.BucketLocationConstraint(region),
+	}
+}
+
+func (a *AWSTools) CreateBucket(bucket string, opts ...CreateBucketOption) error {
+	return a.CreateBucketWithContext(context.Background(), bucket, opts...)
+}
+
+func (a *AWSTools) CreateBucketWithContext(ctx context.Context, bucket string, opts ...CreateBucketOption) error {
+	input := &s3.CreateBucketInput{
+		Bucket:                    aws.String(bucket),
+		CreateBucketConfiguration: bucketConfiguration(a.params.Region()),
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(input)
+		}
+	}
+
+	if _, err := a.s3Client.CreateBucket(ctx, input); err != nil {
+		return fmt.Errorf("unable to create bucket %q, %w", bucket, newOpError("CreateBucket", bucket, "", err))
+	}
+
+	return nil
+}
+
+// DeleteBucketOption customizes a DeleteBucket call.
+type DeleteBucketOption func(*deleteBucketParams)
+
+type deleteBucketParams struct {
+	force bool
+}
+
+// WithForceDelete removes every object and object version from the bucket
+// before deleting it.
+func WithForceDelete() DeleteBucketOption {
+	return func(p *deleteBucketParams) {
+		p.force = true
+	}
+}
+
+func (a *AWSTools) DeleteBucket(bucket string, opts ...DeleteBucketOption) error {
+	return a.DeleteBucketWithContext(context.Background(), bucket, opts...)
+}
+
+func (a *AWSTools) DeleteBucketWithContext(ctx context.Context, bucket string, opts ...DeleteBucketOption) error {
+	params := &deleteBucketParams{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(params)
+		}
+	}
+
+	if params.force {
+		if err := a.emptyBucket(ctx, bucket); err != nil {
+			return err
+		}
+	}
+
+	_, err := a.s3Client.DeleteBucket(ctx, &s3.DeleteBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete bucket %q, %w", bucket, newOpError("DeleteBucket", bucket, "", err))
+	}
+
+	return nil
+}
+
+// emptyBucket deletes every object version and delete marker in bucket.
+// Listing versions also covers unversioned buckets, where every object is
+// reported with the "null" version.
+func (a *AWSTools) emptyBucket(ctx context.Context, bucket string) error {
+	_, err := a.runDeleteBatches(ctx, bucket, a.newDeleteParams(), func(ctx context.Context, batches chan<- []types.ObjectIdentifier) error {
+		paginator := s3.NewListObjectVersionsPaginator(a.s3Client, &s3.ListObjectVersionsInput{
+			Bucket:  aws.String(bucket),
+			MaxKeys: aws.Int32(maxDeleteBatch),
+		})
+
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("unable to list object versions in bucket %q, %w", bucket, newOpError("ListObjectVersions", bucket, "", err))
+			}
+
+			ids := make([]types.ObjectIdentifier, 0, len(page.Versions)+len(page.DeleteMarkers))
+			for _, v := range page.Versions {
+				ids = append(ids, types.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+			}
+			for _, m := range page.DeleteMarkers {
+				ids = append(ids, types.ObjectIdentifier{Key: m.Key, VersionId: m.VersionId})
+			}
+			if len(ids) == 0 {
+				continue
+			}
+
+			select {
+			case batches <- ids:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unable to empty bucket %q, %w", bucket, err)
+	}
+
+	return nil
+}
+
+func (a *AWSTools) BucketExists(bucket string) (bool, error) {
+	return a.BucketExistsWithContext(context.Background(), bucket)
+}
+
+// BucketExistsWithContext reports whether bucket exists. A missing bucket
+// returns false with a nil error; a bucket the credentials are not allowed to
+// access returns false with an error, since its existence cannot be confirmed.
+func (a *AWSTools) BucketExistsWithContext(ctx context.Context, bucket string) (bool, error) {
+	_, err := a.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucket),
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	opErr := newOpError("HeadBucket", bucket, "", err)
+	if errors.Is(opErr, ErrBucketNotFound) {
+		return false, nil
+	}
+	if errors.Is(opErr, ErrAccessDenied) {
+		return false, fmt.Errorf("access denied to bucket %q, %w", bucket, opErr)
+	}
+
+	return false, fmt.Errorf("unable to check bucket %q, %w", bucket, opErr)
+}
@@ -0,0 +1,128 @@
+package awstools
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestNormalizeBucketRegion(t *testing.T) {
+	cases := map[types.BucketLocationConstraint]string{
+		"":                                    "us-east-1",
+		types.BucketLocationConstraintEu:      "eu-west-1",
+		types.BucketLocationConstraintSaEast1: "sa-east-1",
+	}
+
+	for constraint, expected := range cases {
+		if region := normalizeBucketRegion(constraint); region != expected {
+			t.Errorf("Expected region %s for %q, got %s", expected, constraint, region)
+		}
+	}
+}
+
+func TestListBucketsParams(t *testing.T) {
+	params := newListBucketsParams()
+	if params.pageSize != 1000 {
+		t.Errorf("Expected default page size 1000, got %d", params.pageSize)
+	}
+	if !params.resolveRegion {
+		t.Error("Expected region lookup to be enabled by default")
+	}
+
+	params = newListBucketsParams(
+		WithBucketPrefix("logs-"),
+		WithBucketPageSize(50),
+		WithBucketRegionLookup(false),
+	)
+	if params.prefix != "logs-" {
+		t.Errorf("Expected prefix logs-, got %s", params.prefix)
+	}
+	if params.pageSize != 50 {
+		t.Errorf("Expected page size 50, got %d", params.pageSize)
+	}
+	if params.resolveRegion {
+		t.Error("Expected region lookup to be disabled")
+	}
+}
+
+func TestBucketConfiguration(t *testing.T) {
+	if cfg := bucketConfiguration("us-east-1"); cfg != nil {
+		t.Errorf("Expected no configuration for us-east-1, got %v", cfg.LocationConstraint)
+	}
+
+	if cfg := bucketConfiguration(""); cfg != nil {
+		t.Errorf("Expected no configuration for empty region, got %v", cfg.LocationConstraint)
+	}
+
+	cfg := bucketConfiguration("sa-east-1")
+	if cfg == nil || cfg.LocationConstraint != types.BucketLocationConstraintSaEast1 {
+		t.Fatalf("Expected sa-east-1 location constraint, got %v", cfg)
+	}
+}
+
+// TestBucketLifecycleIntegration cria, verifica e remove um bucket temporário
+func TestBucketLifecycleIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	endpoint := os.Getenv("AWS_S3_ENDPOINT")
+
+	if accessKey == "" || secretKey == "" {
+		t.Skip("AWS credentials not set")
+	}
+
+	tools, err := NewAWSTools(
+		WithAccessKeyID(accessKey),
+		WithSecretKey(secretKey),
+		WithRegion("us-east-1"),
+		WithEndpoint(endpoint),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create AWSTools: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	bucket := "awstools-test-" + time.Now().Format("20060102150405")
+
+	if err := tools.CreateBucketWithContext(ctx, bucket); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+
+	exists, err := tools.BucketExistsWithContext(ctx, bucket)
+	if err != nil {
+		t.Fatalf("BucketExists failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("Created bucket not found")
+	}
+
+	tmpFile := "/tmp/test-bucket-lifecycle.txt"
+	if err := os.WriteFile(tmpFile, []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	if err := tools.UploadFileToS3WithContext(ctx, bucket, "file.txt", tmpFile); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if err := tools.DeleteBucketWithContext(ctx, bucket, WithForceDelete()); err != nil {
+		t.Fatalf("DeleteBucket failed: %v", err)
+	}
+
+	exists, err = tools.BucketExistsWithContext(ctx, bucket)
+	if err != nil {
+		t.Fatalf("BucketExists failed: %v", err)
+	}
+	if exists {
+		t.Error("Bucket still exists after delete")
+	}
+}
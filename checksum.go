@@ -0,0 +1,302 @@
+package awstools
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// metaSHA256 is the metadata key, without the x-amz-meta- prefix, holding
+// the hex SHA256 of the full object written by WithUploadSHA256Metadata.
+const metaSHA256 = "awstools-sha256"
+
+// newChecksumHash returns the hash behind an S3 flexible checksum algorithm.
+func newChecksumHash(algorithm types.ChecksumAlgorithm) (hash.Hash, error) {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32c:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	case types.ChecksumAlgorithmCrc32:
+		return crc32.NewIEEE(), nil
+	case types.ChecksumAlgorithmSha256:
+		return sha256.New(), nil
+	case types.ChecksumAlgorithmSha1:
+		return sha1.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+}
+
+// fillSHA256Metadata computes the digest requested by
+// WithUploadSHA256Metadata, reading the body once and rewinding it.
+func fillSHA256Metadata(input *s3.PutObjectInput) error {
+	if digest, ok := input.Metadata[metaSHA256]; !ok || digest != "" {
+		return nil
+	}
+
+	body, ok := input.Body.(io.ReadSeeker)
+	if !ok {
+		return errors.New("SHA256 metadata requires a seekable body")
+	}
+
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return err
+	}
+	if _, err := body.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+
+	input.Metadata[metaSHA256] = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// objectChecksums are the checksums an object can be verified against.
+type objectChecksums struct {
+	etag string
+	// sha256 is the hex full-object digest stored in metadata, if any.
+	sha256 string
+	// algorithm and checksum are the native S3 checksum, if any. For objects
+	// uploaded in parts the checksum is composite ("<checksum>-<parts>") and
+	// parts lists the size and checksum of every part.
+	algorithm types.ChecksumAlgorithm
+	checksum  string
+	parts     []types.ObjectPart
+	// decrypted is set when the reader sees the plaintext of a client-side
+	// encrypted object, to which the native checksum does not apply.
+	decrypted bool
+}
+
+// objectChecksums reads the checksums of the object input refers to.
+func (a *AWSTools) objectChecksums(ctx context.Context, input *s3.GetObjectInput) (*objectChecksums, error) {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	out, err := a.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:               input.Bucket,
+		Key:                  input.Key,
+		VersionId:            input.VersionId,
+		ChecksumMode:         types.ChecksumModeEnabled,
+		SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+		SSECustomerKey:       input.SSECustomerKey,
+		SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read checksums of object %q in bucket %q, %w", key, bucket, newOpError("HeadObject", bucket, key, err))
+	}
+
+	sums := &objectChecksums{
+		etag:      aws.ToString(out.ETag),
+		sha256:    out.Metadata[metaSHA256],
+		decrypted: isEncrypted(out.Metadata) && a.params.ClientEncryption() != nil,
+	}
+
+	for _, native := range []struct {
+		algorithm types.ChecksumAlgorithm
+		value     *string
+	}{
+		{types.ChecksumAlgorithmCrc32c, out.ChecksumCRC32C},
+		{types.ChecksumAlgorithmSha256, out.ChecksumSHA256},
+		{types.ChecksumAlgorithmCrc32, out.ChecksumCRC32},
+		{types.ChecksumAlgorithmSha1, out.ChecksumSHA1},
+	} {
+		if aws.ToString(native.value) != "" {
+			sums.algorithm, sums.checksum = native.algorithm, aws.ToString(native.value)
+			break
+		}
+	}
+
+	if sums.decrypted {
+		sums.checksum = ""
+	}
+
+	if strings.Contains(sums.checksum, "-") {
+		if sums.parts, err = a.objectParts(ctx, input); err != nil {
+			return nil, err
+		}
+		// A composite checksum can only be checked part by part.
+		if len(sums.parts) == 0 {
+			sums.checksum = ""
+		}
+	}
+
+	if sums.sha256 == "" && sums.checksum == "" && !sums.decrypted {
+		return nil, fmt.Errorf("object %q in bucket %q has no checksum to verify", key, bucket)
+	}
+
+	// Decrypted content without a SHA256 is still verified: AES-GCM
+	// authenticates every segment.
+	return sums, nil
+}
+
+// objectParts lists the parts of an object uploaded in parts.
+func (a *AWSTools) objectParts(ctx context.Context, input *s3.GetObjectInput) ([]types.ObjectPart, error) {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	var parts []types.ObjectPart
+	var marker *string
+	for {
+		out, err := a.s3Client.GetObjectAttributes(ctx, &s3.GetObjectAttributesInput{
+			Bucket:               input.Bucket,
+			Key:                  input.Key,
+			VersionId:            input.VersionId,
+			ObjectAttributes:     []types.ObjectAttributes{types.ObjectAttributesObjectParts},
+			PartNumberMarker:     marker,
+			SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+			SSECustomerKey:       input.SSECustomerKey,
+			SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list parts of object %q in bucket %q, %w",
+				key, bucket, newOpError("GetObjectAttributes", bucket, key, err))
+		}
+		if out.ObjectParts == nil {
+			return parts, nil
+		}
+
+		parts = append(parts, out.ObjectParts.Parts...)
+		if !aws.ToBool(out.ObjectParts.IsTruncated) {
+			return parts, nil
+		}
+		marker = out.ObjectParts.NextPartNumberMarker
+	}
+}
+
+// checksumVerifier hashes the object content written to it and checks it
+// against objectChecksums. Composite checksums are verified part by part.
+type checksumVerifier struct {
+	sums *objectChecksums
+
+	full   hash.Hash // metadata SHA256
+	native hash.Hash // native checksum of the object or of the current part
+
+	part     int
+	partLeft int64
+	err      error
+}
+
+func newChecksumVerifier(sums *objectChecksums) (*checksumVerifier, error) {
+	v := &checksumVerifier{sums: sums}
+	if sums.sha256 != "" {
+		v.full = sha256.New()
+	}
+	if sums.checksum != "" {
+		h, err := newChecksumHash(sums.algorithm)
+		if err != nil {
+			return nil, err
+		}
+		v.native = h
+		if len(sums.parts) > 0 {
+			v.partLeft = aws.ToInt64(sums.parts[0].Size)
+		}
+	}
+	return v, nil
+}
+
+func (v *checksumVerifier) Write(p []byte) (int, error) {
+	if v.full != nil {
+		v.full.Write(p)
+	}
+	if v.native == nil {
+		return len(p), nil
+	}
+	if len(v.sums.parts) == 0 {
+		v.native.Write(p)
+		return len(p), nil
+	}
+
+	for rest := p; len(rest) > 0; {
+		if v.part >= len(v.sums.parts) {
+			v.fail(fmt.Errorf("%w: object is larger than its parts", ErrChecksumMismatch))
+			break
+		}
+		n := int64(len(rest))
+		if n > v.partLeft {
+			n = v.partLeft
+		}
+		v.native.Write(rest[:n])
+		rest, v.partLeft = rest[n:], v.partLeft-n
+		if v.partLeft == 0 {
+			v.finishPart()
+		}
+	}
+	return len(p), nil
+}
+
+// finishPart checks the part that was just completed and starts the next one.
+func (v *checksumVerifier) finishPart() {
+	part := v.sums.parts[v.part]
+	want := partChecksum(part, v.sums.algorithm)
+	if got := base64.StdEncoding.EncodeToString(v.native.Sum(nil)); want != "" && got != want {
+		v.fail(fmt.Errorf("%w: part %d %s expected %s, got %s",
+			ErrChecksumMismatch, aws.ToInt32(part.PartNumber), v.sums.algorithm, want, got))
+	}
+
+	v.native.Reset()
+	v.part++
+	if v.part < len(v.sums.parts) {
+		v.partLeft = aws.ToInt64(v.sums.parts[v.part].Size)
+	}
+}
+
+func (v *checksumVerifier) fail(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+// Verify reports whether everything written matches the expected checksums.
+func (v *checksumVerifier) Verify() error {
+	if v.err != nil {
+		return v.err
+	}
+
+	if v.full != nil {
+		if got := hex.EncodeToString(v.full.Sum(nil)); got != v.sums.sha256 {
+			return fmt.Errorf("%w: SHA256 expected %s, got %s", ErrChecksumMismatch, v.sums.sha256, got)
+		}
+	}
+
+	switch {
+	case v.native == nil:
+	case len(v.sums.parts) > 0:
+		if v.part != len(v.sums.parts) {
+			return fmt.Errorf("%w: object is missing parts, read %d of %d", ErrChecksumMismatch, v.part, len(v.sums.parts))
+		}
+	default:
+		if got := base64.StdEncoding.EncodeToString(v.native.Sum(nil)); got != v.sums.checksum {
+			return fmt.Errorf("%w: %s expected %s, got %s", ErrChecksumMismatch, v.sums.algorithm, v.sums.checksum, got)
+		}
+	}
+
+	return nil
+}
+
+func partChecksum(part types.ObjectPart, algorithm types.ChecksumAlgorithm) string {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32c:
+		return aws.ToString(part.ChecksumCRC32C)
+	case types.ChecksumAlgorithmCrc32:
+		return aws.ToString(part.ChecksumCRC32)
+	case types.ChecksumAlgorithmSha256:
+		return aws.ToString(part.ChecksumSHA256)
+	case types.ChecksumAlgorithmSha1:
+		return aws.ToString(part.ChecksumSHA1)
+	default:
+		return ""
+	}
+}
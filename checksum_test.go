@@ -0,0 +1,137 @@
+package awstools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func crc32cBase64(data []byte) string {
+	sum := crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+	return base64.StdEncoding.EncodeToString([]byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8), byte(sum)})
+}
+
+func verifyContent(t *testing.T, sums *objectChecksums, content []byte) error {
+	t.Helper()
+
+	verifier, err := newChecksumVerifier(sums)
+	if err != nil {
+		t.Fatalf("newChecksumVerifier failed: %v", err)
+	}
+	// Odd write sizes exercise part boundaries falling inside a write.
+	for rest := content; len(rest) > 0; {
+		n := 7
+		if n > len(rest) {
+			n = len(rest)
+		}
+		verifier.Write(rest[:n])
+		rest = rest[n:]
+	}
+	return verifier.Verify()
+}
+
+func TestChecksumVerifierFullObject(t *testing.T) {
+	content := []byte("hello checksum")
+	digest := sha256.Sum256(content)
+
+	sums := &objectChecksums{
+		sha256:    hex.EncodeToString(digest[:]),
+		algorithm: types.ChecksumAlgorithmCrc32c,
+		checksum:  crc32cBase64(content),
+	}
+	if err := verifyContent(t, sums, content); err != nil {
+		t.Errorf("Expected content to verify, got %v", err)
+	}
+
+	err := verifyContent(t, sums, []byte("hello checksuM"))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	sums.checksum = ""
+	if err := verifyContent(t, sums, []byte("tampered")); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected SHA256 metadata mismatch, got %v", err)
+	}
+}
+
+func TestChecksumVerifierComposite(t *testing.T) {
+	content := []byte(strings.Repeat("a", 20) + strings.Repeat("b", 20) + strings.Repeat("c", 5))
+	split := [][]byte{content[:20], content[20:40], content[40:]}
+
+	parts := make([]types.ObjectPart, len(split))
+	for i, data := range split {
+		parts[i] = types.ObjectPart{
+			PartNumber:     aws.Int32(int32(i + 1)),
+			Size:           aws.Int64(int64(len(data))),
+			ChecksumCRC32C: aws.String(crc32cBase64(data)),
+		}
+	}
+
+	sums := &objectChecksums{
+		algorithm: types.ChecksumAlgorithmCrc32c,
+		checksum:  "composite-3",
+		parts:     parts,
+	}
+	if err := verifyContent(t, sums, content); err != nil {
+		t.Errorf("Expected composite content to verify, got %v", err)
+	}
+
+	corrupted := bytes.Clone(content)
+	corrupted[25] = 'x'
+	if err := verifyContent(t, sums, corrupted); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected part mismatch, got %v", err)
+	}
+	if err := verifyContent(t, sums, content[:30]); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected missing parts error, got %v", err)
+	}
+	if err := verifyContent(t, sums, append(bytes.Clone(content), 'z')); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected extra data error, got %v", err)
+	}
+}
+
+func TestFillSHA256Metadata(t *testing.T) {
+	content := "file content"
+	body := strings.NewReader(content)
+	input := &s3.PutObjectInput{Body: body}
+
+	WithUploadSHA256Metadata()(input)
+	if err := fillSHA256Metadata(input); err != nil {
+		t.Fatalf("fillSHA256Metadata failed: %v", err)
+	}
+
+	digest := sha256.Sum256([]byte(content))
+	if input.Metadata[metaSHA256] != hex.EncodeToString(digest[:]) {
+		t.Errorf("Unexpected digest: %s", input.Metadata[metaSHA256])
+	}
+	if body.Len() != len(content) {
+		t.Error("Body was not rewound after hashing")
+	}
+
+	input = &s3.PutObjectInput{Body: bytes.NewBufferString(content)}
+	WithUploadSHA256Metadata()(input)
+	if err := fillSHA256Metadata(input); err == nil {
+		t.Error("Expected an error for a body that cannot be rewound")
+	}
+}
+
+func TestChecksumOptions(t *testing.T) {
+	put := &s3.PutObjectInput{}
+	WithUploadChecksum(types.ChecksumAlgorithmSha256)(put)
+	if put.ChecksumAlgorithm != types.ChecksumAlgorithmSha256 {
+		t.Errorf("Expected SHA256 checksum algorithm, got %s", put.ChecksumAlgorithm)
+	}
+
+	get := newDownloadParams(&s3.GetObjectInput{}, WithDownloadChecksumValidation()).input
+	if get.ChecksumMode != types.ChecksumModeEnabled {
+		t.Errorf("Expected checksum mode enabled, got %s", get.ChecksumMode)
+	}
+}
@@ -0,0 +1,431 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// CopyResult describes the object written by a copy or move.
+type CopyResult struct {
+	Bucket    string
+	Key       string
+	ETag      string
+	VersionID string
+}
+
+// CopyOption customizes copies and moves. Most options set fields of the S3
+// CopyObjectInput; the rest configure how the copy is carried out.
+type CopyOption func(*copyParams)
+
+type copyParams struct {
+	input    *s3.CopyObjectInput
+	progress ProgressFunc
+	// stampSourceETag records the source ETag in the metadata of objects
+	// copied in parts, for WithSkipExisting.
+	stampSourceETag bool
+	// err records an invalid option, returned before any request is made.
+	err error
+}
+
+// newCopyParams applies opts to input, which already names the source and
+// destination.
+func newCopyParams(input *s3.CopyObjectInput, opts ...CopyOption) *copyParams {
+	p := &copyParams{input: input}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// WithCopyMetadataDirective chooses whether the destination keeps the source
+// metadata (COPY, the default) or takes the metadata given in the request
+// (REPLACE).
+func WithCopyMetadataDirective(directive types.MetadataDirective) CopyOption {
+	return func(p *copyParams) {
+		p.input.MetadataDirective = directive
+	}
+}
+
+// WithCopyMetadata replaces the destination metadata with metadata. It
+// implies the REPLACE metadata directive. The client-side encryption
+// envelope of the source is always kept.
+func WithCopyMetadata(metadata map[string]string) CopyOption {
+	return func(p *copyParams) {
+		p.input.MetadataDirective = types.MetadataDirectiveReplace
+		if p.input.Metadata == nil {
+			p.input.Metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			p.input.Metadata[k] = v
+		}
+	}
+}
+
+// WithCopyContentType sets the Content-Type of the destination. It implies
+// the REPLACE metadata directive.
+func WithCopyContentType(contentType string) CopyOption {
+	return func(p *copyParams) {
+		p.input.MetadataDirective = types.MetadataDirectiveReplace
+		p.input.ContentType = aws.String(contentType)
+	}
+}
+
+// WithCopyACL applies a canned ACL to the destination object.
+func WithCopyACL(acl types.ObjectCannedACL) CopyOption {
+	return func(p *copyParams) {
+		p.input.ACL = acl
+	}
+}
+
+// WithCopyStorageClass sets the storage class of the destination object.
+func WithCopyStorageClass(class types.StorageClass) CopyOption {
+	return func(p *copyParams) {
+		p.input.StorageClass = class
+	}
+}
+
+// WithCopySourceIfMatch only copies the source when its ETag matches etag.
+// By default copies are pinned to the ETag seen when the copy starts.
+func WithCopySourceIfMatch(etag string) CopyOption {
+	return func(p *copyParams) {
+		p.input.CopySourceIfMatch = aws.String(`"` + trimETag(etag) + `"`)
+	}
+}
+
+// WithCopyTags replaces the destination tags with tags instead of copying
+// the source tags.
+func WithCopyTags(tags map[string]string) CopyOption {
+	return func(p *copyParams) {
+		p.input.TaggingDirective = types.TaggingDirectiveReplace
+		p.input.Tagging = aws.String(encodeTags(tags))
+	}
+}
+
+// WithCopySSES3 encrypts the destination with S3 managed keys (SSE-S3).
+func WithCopySSES3() CopyOption {
+	return func(p *copyParams) {
+		p.input.ServerSideEncryption = types.ServerSideEncryptionAes256
+	}
+}
+
+// WithCopySSEKMS encrypts the destination with a KMS key (SSE-KMS). An empty
+// keyID selects the AWS managed key of the account.
+func WithCopySSEKMS(keyID string) CopyOption {
+	return func(p *copyParams) {
+		p.input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		p.input.SSEKMSKeyId = optionalString(keyID)
+	}
+}
+
+// WithCopySSEKMSContext sets the encryption context of an SSE-KMS destination.
+func WithCopySSEKMSContext(encryptionContext map[string]string) CopyOption {
+	return func(p *copyParams) {
+		p.input.SSEKMSEncryptionContext = encodeKMSContext(encryptionContext)
+	}
+}
+
+// WithCopyBucketKey enables or disables the S3 Bucket Key for an SSE-KMS
+// destination, overriding the bucket setting.
+func WithCopyBucketKey(enabled bool) CopyOption {
+	return func(p *copyParams) {
+		p.input.BucketKeyEnabled = aws.Bool(enabled)
+	}
+}
+
+// WithCopySSECustomerKey encrypts the destination with a 256-bit customer
+// provided key (SSE-C). The copy fails with ErrInvalidSSECustomerKey if key
+// is not 32 bytes long.
+func WithCopySSECustomerKey(key []byte) CopyOption {
+	return func(p *copyParams) {
+		if err := checkSSECustomerKey(key); err != nil {
+			p.err = err
+			return
+		}
+		sse := newSSECustomerKey(key)
+		p.input.SSECustomerAlgorithm = sse.algorithm
+		p.input.SSECustomerKey = sse.key
+		p.input.SSECustomerKeyMD5 = sse.keyMD5
+	}
+}
+
+// WithCopySourceSSECustomerKey supplies the customer provided key (SSE-C)
+// the source was encrypted with. The copy fails with
+// ErrInvalidSSECustomerKey if key is not 32 bytes long.
+func WithCopySourceSSECustomerKey(key []byte) CopyOption {
+	return func(p *copyParams) {
+		if err := checkSSECustomerKey(key); err != nil {
+			p.err = err
+			return
+		}
+		sse := newSSECustomerKey(key)
+		p.input.CopySourceSSECustomerAlgorithm = sse.algorithm
+		p.input.CopySourceSSECustomerKey = sse.key
+		p.input.CopySourceSSECustomerKeyMD5 = sse.keyMD5
+	}
+}
+
+// WithCopyProgress reports the progress of the copy to fn. Server-side
+// copies report the bytes of every completed part, or the whole object at
+// once when it is copied in a single request.
+func WithCopyProgress(fn ProgressFunc) CopyOption {
+	return func(p *copyParams) {
+		p.progress = fn
+	}
+}
+
+// WithCopySourceVersion copies the given version of the source object
+// instead of the current one.
+func WithCopySourceVersion(versionID string) CopyOption {
+	return func(p *copyParams) {
+		source, _, _ := strings.Cut(aws.ToString(p.input.CopySource), "?")
+		p.input.CopySource = aws.String(source + "?versionId=" + url.QueryEscape(versionID))
+	}
+}
+
+func (a *AWSTools) CopyObject(srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	return a.CopyObjectWithContext(context.Background(), srcBucket, srcKey, dstBucket, dstKey, opts...)
+}
+
+// CopyObjectWithContext performs a server-side copy of srcBucket/srcKey to
+// dstBucket/dstKey. Both buckets must be reachable with this instance's
+// credentials; use CopyObjectTo to copy between accounts or endpoints.
+// Sources larger than the multipart copy threshold are copied in parts.
+func (a *AWSTools) CopyObjectWithContext(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	result, _, err := a.copyObject(ctx, srcBucket, srcKey, dstBucket, dstKey, opts...)
+	return result, err
+}
+
+// copyObject copies the object and also returns the source metadata read
+// before the copy. The copy is pinned to that source ETag, so a source
+// rewritten in between fails with ErrPreconditionFailed.
+func (a *AWSTools) copyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, *ObjectInfo, error) {
+	params := newCopyParams(&s3.CopyObjectInput{
+		Bucket:     aws.String(dstBucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(copySource(srcBucket, srcKey)),
+	}, opts...)
+	if params.err != nil {
+		return nil, nil, params.err
+	}
+	input := params.input
+
+	src, err := a.statObject(ctx, &s3.HeadObjectInput{
+		Bucket:               aws.String(srcBucket),
+		Key:                  aws.String(srcKey),
+		VersionId:            sourceVersion(input.CopySource),
+		SSECustomerAlgorithm: input.CopySourceSSECustomerAlgorithm,
+		SSECustomerKey:       input.CopySourceSSECustomerKey,
+		SSECustomerKeyMD5:    input.CopySourceSSECustomerKeyMD5,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if input.CopySourceIfMatch == nil {
+		input.CopySourceIfMatch = aws.String(`"` + src.ETag + `"`)
+	}
+	if input.MetadataDirective == types.MetadataDirectiveReplace {
+		input.Metadata = withEnvelope(input.Metadata, src.Metadata)
+	}
+
+	tracker := newProgressTracker(params.progress, "copy", dstBucket, dstKey, src.Size)
+	if src.Size > a.params.MultipartCopyThreshold() {
+		result, err := a.multipartCopy(ctx, src, input, params.stampSourceETag, tracker)
+		if err != nil {
+			return nil, nil, err
+		}
+		tracker.finish()
+		return result, src, nil
+	}
+
+	out, err := a.s3Client.CopyObject(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to copy object %q from bucket %q to %q in bucket %q, %w",
+			srcKey, srcBucket, dstKey, dstBucket, newOpError("CopyObject", dstBucket, dstKey, err))
+	}
+
+	result := &CopyResult{
+		Bucket:    dstBucket,
+		Key:       dstKey,
+		VersionID: aws.ToString(out.VersionId),
+	}
+	if out.CopyObjectResult != nil {
+		result.ETag = trimETag(aws.ToString(out.CopyObjectResult.ETag))
+	}
+
+	tracker.add(src.Size)
+	tracker.finish()
+	return result, src, nil
+}
+
+func (a *AWSTools) CopyObjectTo(dst *AWSTools, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	return a.CopyObjectToWithContext(context.Background(), dst, srcBucket, srcKey, dstBucket, dstKey, opts...)
+}
+
+// CopyObjectToWithContext copies an object from this instance to dst, which
+// may use different credentials or endpoint. The object is streamed through
+// the process: it is read with this instance and uploaded with dst. Content
+// headers, metadata and tags are carried over unless the matching REPLACE
+// directive is given.
+//
+// Client-side encryption applies on each side: the source is decrypted
+// with the key wrapper of this instance and the destination is encrypted
+// with the one of dst, if any.
+func (a *AWSTools) CopyObjectToWithContext(ctx context.Context, dst *AWSTools, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	result, _, err := a.copyObjectTo(ctx, dst, srcBucket, srcKey, dstBucket, dstKey, opts...)
+	return result, err
+}
+
+// copyObjectTo streams the object to dst and also returns the plaintext size
+// and the ETag of the source that was read.
+func (a *AWSTools) copyObjectTo(ctx context.Context, dst *AWSTools, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, *ObjectInfo, error) {
+	if dst == nil {
+		return nil, nil, errors.New("destination AWSTools is nil")
+	}
+
+	params := newCopyParams(&s3.CopyObjectInput{
+		CopySource: aws.String(copySource(srcBucket, srcKey)),
+	}, opts...)
+	if params.err != nil {
+		return nil, nil, params.err
+	}
+	copyInput := params.input
+
+	src, err := a.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:               aws.String(srcBucket),
+		Key:                  aws.String(srcKey),
+		VersionId:            sourceVersion(copyInput.CopySource),
+		IfMatch:              copyInput.CopySourceIfMatch,
+		SSECustomerAlgorithm: copyInput.CopySourceSSECustomerAlgorithm,
+		SSECustomerKey:       copyInput.CopySourceSSECustomerKey,
+		SSECustomerKeyMD5:    copyInput.CopySourceSSECustomerKeyMD5,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get file, %w", newOpError("GetObject", srcBucket, srcKey, err))
+	}
+	defer src.Body.Close()
+
+	body, err := a.plaintextBody(ctx, src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt object %q in bucket %q, %w", srcKey, srcBucket, err)
+	}
+	size := plaintextSize(&ObjectInfo{Size: aws.ToInt64(src.ContentLength), Metadata: src.Metadata})
+
+	tracker := newProgressTracker(params.progress, "copy", dstBucket, dstKey, size)
+
+	input := &s3.PutObjectInput{
+		Bucket:                  aws.String(dstBucket),
+		Key:                     aws.String(dstKey),
+		Body:                    body,
+		ACL:                     copyInput.ACL,
+		StorageClass:            copyInput.StorageClass,
+		ServerSideEncryption:    copyInput.ServerSideEncryption,
+		SSEKMSKeyId:             copyInput.SSEKMSKeyId,
+		SSEKMSEncryptionContext: copyInput.SSEKMSEncryptionContext,
+		BucketKeyEnabled:        copyInput.BucketKeyEnabled,
+		SSECustomerAlgorithm:    copyInput.SSECustomerAlgorithm,
+		SSECustomerKey:          copyInput.SSECustomerKey,
+		SSECustomerKeyMD5:       copyInput.SSECustomerKeyMD5,
+	}
+
+	if copyInput.MetadataDirective == types.MetadataDirectiveReplace {
+		input.ContentType = copyInput.ContentType
+		input.ContentEncoding = copyInput.ContentEncoding
+		input.ContentDisposition = copyInput.ContentDisposition
+		input.ContentLanguage = copyInput.ContentLanguage
+		input.CacheControl = copyInput.CacheControl
+		input.Metadata = copyInput.Metadata
+	} else {
+		input.ContentType = src.ContentType
+		input.ContentEncoding = src.ContentEncoding
+		input.ContentDisposition = src.ContentDisposition
+		input.ContentLanguage = src.ContentLanguage
+		input.CacheControl = src.CacheControl
+		// The plaintext is copied, so the source envelope does not apply.
+		input.Metadata = withoutEnvelope(src.Metadata)
+	}
+
+	if copyInput.TaggingDirective == types.TaggingDirectiveReplace {
+		input.Tagging = copyInput.Tagging
+	} else if aws.ToInt32(src.TagCount) > 0 {
+		tagSet, err := a.getObjectTagSet(ctx, srcBucket, srcKey, aws.ToString(src.VersionId))
+		if err != nil {
+			return nil, nil, err
+		}
+		input.Tagging = aws.String(encodeTagSet(tagSet))
+	}
+
+	out, err := dst.sendUpload(ctx, input, size, UploadTransfer{}, tracker)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracker.finish()
+
+	result := &CopyResult{
+		Bucket:    dstBucket,
+		Key:       dstKey,
+		ETag:      trimETag(aws.ToString(out.ETag)),
+		VersionID: aws.ToString(out.VersionID),
+	}
+	info := &ObjectInfo{
+		Bucket:    srcBucket,
+		Key:       srcKey,
+		Size:      size,
+		ETag:      trimETag(aws.ToString(src.ETag)),
+		VersionID: aws.ToString(src.VersionId),
+	}
+
+	return result, info, nil
+}
+
+// copySource builds the x-amz-copy-source value for bucket/key. The key is
+// URL-encoded, keeping "/" separators intact.
+func copySource(bucket, key string) string {
+	return bucket + "/" + encodeKey(key)
+}
+
+// sourceVersion extracts the versionId set by WithCopySourceVersion from an
+// x-amz-copy-source value. The key itself never contains a raw "?", since
+// encodeKey escapes it.
+func sourceVersion(copySource *string) *string {
+	_, query, ok := strings.Cut(aws.ToString(copySource), "?")
+	if !ok {
+		return nil
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil
+	}
+	return optionalString(values.Get("versionId"))
+}
+
+// encodeKey percent-encodes every byte of key except unreserved characters
+// and "/".
+func encodeKey(key string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	b.Grow(len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		}
+	}
+	return b.String()
+}
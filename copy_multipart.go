@@ -0,0 +1,274 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// maxSingleCopySize is the largest object CopyObject accepts (5 GiB).
+	maxSingleCopySize = 5 * 1024 * 1024 * 1024
+	// minPartSize is the smallest part S3 accepts, except for the last one.
+	minPartSize = 5 * 1024 * 1024
+	// maxParts is the maximum number of parts in a multipart upload.
+	maxParts = 10000
+)
+
+// metaSourceETag holds the ETag of the source of a multipart copy, whose
+// own ETag differs from it. It is only written for WithSkipExisting.
+const metaSourceETag = "awstools-source-etag"
+
+// copyPart is a byte range of the source copied by one UploadPartCopy call.
+type copyPart struct {
+	number int32
+	start  int64
+	end    int64 // inclusive
+}
+
+// planCopyParts splits size bytes into parts of partSize, growing the part
+// size when the object would otherwise need more than maxParts parts.
+func planCopyParts(size, partSize int64) []copyPart {
+	if min := (size + maxParts - 1) / maxParts; partSize < min {
+		partSize = min
+	}
+
+	parts := make([]copyPart, 0, (size+partSize-1)/partSize)
+	for start, n := int64(0), int32(1); start < size; start, n = start+partSize, n+1 {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		parts = append(parts, copyPart{number: n, start: start, end: end})
+	}
+	return parts
+}
+
+// multipartCopy copies src to the destination named in input using
+// UploadPartCopy. Metadata, content headers, tags and encryption settings
+// are taken from the source unless input overrides them, mirroring what a
+// single CopyObject would do. With stamp, the source ETag is added to the
+// metadata copied from the source.
+func (a *AWSTools) multipartCopy(ctx context.Context, src *ObjectInfo, input *s3.CopyObjectInput, stamp bool, tracker *progressTracker) (*CopyResult, error) {
+	dstBucket, dstKey := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	create, err := a.multipartCopyCreateInput(ctx, src, input, stamp)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := a.s3Client.CreateMultipartUpload(ctx, create)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start multipart copy to %q in bucket %q, %w",
+			dstKey, dstBucket, newOpError("CreateMultipartUpload", dstBucket, dstKey, err))
+	}
+
+	completed, err := a.copyParts(ctx, src, input, upload.UploadId, tracker)
+	if err != nil {
+		a.abortMultipartUpload(ctx, dstBucket, dstKey, upload.UploadId)
+		return nil, err
+	}
+
+	out, err := a.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:               input.Bucket,
+		Key:                  input.Key,
+		UploadId:             upload.UploadId,
+		MultipartUpload:      &types.CompletedMultipartUpload{Parts: completed},
+		SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+		SSECustomerKey:       input.SSECustomerKey,
+		SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+	})
+	if err != nil {
+		a.abortMultipartUpload(ctx, dstBucket, dstKey, upload.UploadId)
+		return nil, fmt.Errorf("unable to complete multipart copy to %q in bucket %q, %w",
+			dstKey, dstBucket, newOpError("CompleteMultipartUpload", dstBucket, dstKey, err))
+	}
+
+	return &CopyResult{
+		Bucket:    dstBucket,
+		Key:       dstKey,
+		ETag:      trimETag(aws.ToString(out.ETag)),
+		VersionID: aws.ToString(out.VersionId),
+	}, nil
+}
+
+func (a *AWSTools) multipartCopyCreateInput(ctx context.Context, src *ObjectInfo, input *s3.CopyObjectInput, stamp bool) (*s3.CreateMultipartUploadInput, error) {
+	create := &s3.CreateMultipartUploadInput{
+		Bucket:                    input.Bucket,
+		Key:                       input.Key,
+		ACL:                       input.ACL,
+		StorageClass:              input.StorageClass,
+		ServerSideEncryption:      input.ServerSideEncryption,
+		SSEKMSKeyId:               input.SSEKMSKeyId,
+		SSEKMSEncryptionContext:   input.SSEKMSEncryptionContext,
+		BucketKeyEnabled:          input.BucketKeyEnabled,
+		SSECustomerAlgorithm:      input.SSECustomerAlgorithm,
+		SSECustomerKey:            input.SSECustomerKey,
+		SSECustomerKeyMD5:         input.SSECustomerKeyMD5,
+		ObjectLockMode:            input.ObjectLockMode,
+		ObjectLockRetainUntilDate: input.ObjectLockRetainUntilDate,
+		ObjectLockLegalHoldStatus: input.ObjectLockLegalHoldStatus,
+	}
+
+	if input.MetadataDirective == types.MetadataDirectiveReplace {
+		create.ContentType = input.ContentType
+		create.ContentEncoding = input.ContentEncoding
+		create.ContentDisposition = input.ContentDisposition
+		create.ContentLanguage = input.ContentLanguage
+		create.CacheControl = input.CacheControl
+		create.Metadata = input.Metadata
+	} else {
+		create.ContentType = optionalString(src.ContentType)
+		create.ContentEncoding = optionalString(src.ContentEncoding)
+		create.ContentDisposition = optionalString(src.ContentDisposition)
+		create.ContentLanguage = optionalString(src.ContentLanguage)
+		create.CacheControl = optionalString(src.CacheControl)
+
+		// The metadata given with REPLACE is left as is; the stamp of an
+		// earlier copy of the source is not carried over.
+		create.Metadata = make(map[string]string, len(src.Metadata)+1)
+		maps.Copy(create.Metadata, src.Metadata)
+		delete(create.Metadata, metaSourceETag)
+		if stamp {
+			create.Metadata[metaSourceETag] = src.ETag
+		}
+	}
+
+	if create.StorageClass == "" {
+		create.StorageClass = src.StorageClass
+	}
+
+	// SSE-C keys cannot be inherited; only S3 and KMS managed encryption is.
+	if create.ServerSideEncryption == "" && create.SSECustomerAlgorithm == nil &&
+		src.ServerSideEncryption != "" {
+		create.ServerSideEncryption = src.ServerSideEncryption
+		create.SSEKMSKeyId = optionalString(src.SSEKMSKeyID)
+		if src.BucketKeyEnabled {
+			create.BucketKeyEnabled = aws.Bool(true)
+		}
+	}
+
+	if input.TaggingDirective == types.TaggingDirectiveReplace {
+		create.Tagging = input.Tagging
+		return create, nil
+	}
+
+	tagSet, err := a.getObjectTagSet(ctx, src.Bucket, src.Key, src.VersionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tagSet) > 0 {
+		create.Tagging = aws.String(encodeTagSet(tagSet))
+	}
+
+	return create, nil
+}
+
+// copyParts copies every part of src concurrently. Every part carries the
+// source ETag condition so that a source rewritten mid-copy fails the copy
+// instead of mixing data.
+func (a *AWSTools) copyParts(ctx context.Context, src *ObjectInfo, input *s3.CopyObjectInput, uploadID *string, tracker *progressTracker) ([]types.CompletedPart, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	dstBucket, dstKey := aws.ToString(input.Bucket), aws.ToString(input.Key)
+	parts := planCopyParts(src.Size, a.params.MultipartCopyPartSize())
+	completed := make([]types.CompletedPart, len(parts))
+	jobs := make(chan int)
+
+	mu := &sync.Mutex{}
+	var firstErr error
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < a.queueWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				part := parts[idx]
+				out, err := a.s3Client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+					Bucket:                         input.Bucket,
+					Key:                            input.Key,
+					UploadId:                       uploadID,
+					PartNumber:                     aws.Int32(part.number),
+					CopySource:                     input.CopySource,
+					CopySourceRange:                aws.String(fmt.Sprintf("bytes=%d-%d", part.start, part.end)),
+					CopySourceIfMatch:              input.CopySourceIfMatch,
+					CopySourceSSECustomerAlgorithm: input.CopySourceSSECustomerAlgorithm,
+					CopySourceSSECustomerKey:       input.CopySourceSSECustomerKey,
+					CopySourceSSECustomerKeyMD5:    input.CopySourceSSECustomerKeyMD5,
+					SSECustomerAlgorithm:           input.SSECustomerAlgorithm,
+					SSECustomerKey:                 input.SSECustomerKey,
+					SSECustomerKeyMD5:              input.SSECustomerKeyMD5,
+				})
+				if err != nil {
+					err = newOpError("UploadPartCopy", dstBucket, dstKey, err)
+				} else if out.CopyPartResult == nil || out.CopyPartResult.ETag == nil {
+					err = errors.New("response has no part ETag")
+				}
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("unable to copy part %d to %q in bucket %q, %w",
+							part.number, dstKey, dstBucket, err)
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				completed[idx] = types.CompletedPart{
+					ETag:       out.CopyPartResult.ETag,
+					PartNumber: aws.Int32(part.number),
+				}
+				tracker.add(part.end - part.start + 1)
+				tracker.partDone()
+			}
+		}()
+	}
+
+dispatch:
+	for idx := range parts {
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return completed, nil
+}
+
+// abortMultipartUpload releases the parts of a failed upload. It runs even
+// when ctx is already cancelled, and its own failure is not reported since
+// the caller is already returning the original error.
+func (a *AWSTools) abortMultipartUpload(ctx context.Context, bucket, key string, uploadID *string) {
+	_, _ = a.s3Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+}
+
+// optionalString returns nil for an empty string so that unset headers are
+// not sent.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
@@ -0,0 +1,109 @@
+package awstools_test
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+func TestMultipartCopyMissingPartETag(t *testing.T) {
+	srv := awstoolstest.NewServer()
+	defer srv.Close()
+	srv.PutObject("bucket", "big", bytes.Repeat([]byte("x"), 11*1024*1024))
+
+	// The proxy answers UploadPartCopy with an empty CopyPartResult, as some
+	// S3-compatible servers do, and counts the completion requests.
+	var completions atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPut && r.Header.Get("x-amz-copy-source") != "" && query.Has("partNumber"):
+			w.Write([]byte("<CopyPartResult/>"))
+			return
+		case r.Method == http.MethodPost && query.Has("uploadId"):
+			completions.Add(1)
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	opts := append(srv.Options(),
+		awstools.WithEndpoint(proxy.URL),
+		awstools.WithMultipartCopyThreshold(6*1024*1024),
+		awstools.WithMultipartCopyPartSize(5*1024*1024))
+	tools, err := awstools.NewAWSTools(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tools.CopyObject("bucket", "big", "bucket", "copy"); err == nil {
+		t.Fatal("Expected an error when the part ETag is missing")
+	}
+	if completions.Load() != 0 {
+		t.Error("Expected the upload not to be completed without part ETags")
+	}
+	if srv.Uploads() != 0 {
+		t.Errorf("Expected the failed copy to be aborted, got %d pending uploads", srv.Uploads())
+	}
+	if _, ok := srv.Object("bucket", "copy"); ok {
+		t.Error("Expected no destination object")
+	}
+}
+
+func TestMultipartCopyMetadata(t *testing.T) {
+	ctx := context.Background()
+	tools, _ := newPrefixTestTools(t)
+	store := tools.Store("bucket", "")
+	big := bytes.Repeat([]byte("x"), 11*1024*1024)
+	if err := store.Put(ctx, "src/big", bytes.NewReader(big),
+		awstools.WithUploadMetadata(map[string]string{"owner": "ana"})); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := func(key string) map[string]string {
+		t.Helper()
+		info, err := tools.StatObject("bucket", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Metadata
+	}
+
+	if _, err := tools.CopyObject("bucket", "src/big", "bucket", "copy"); err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata("copy"); !maps.Equal(got, map[string]string{"owner": "ana"}) {
+		t.Errorf("Expected only the source metadata, got %v", got)
+	}
+
+	replaced := map[string]string{"team": "data"}
+	if _, err := tools.CopyPrefix("bucket", "src/", "bucket", "replaced/", awstools.WithSkipExisting(),
+		awstools.WithPrefixCopyOptions(awstools.WithCopyMetadata(replaced))); err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata("replaced/big"); !maps.Equal(got, replaced) {
+		t.Errorf("Expected exactly the replaced metadata, got %v", got)
+	}
+
+	// A resumable run records the source ETag; copies of its output do not
+	// carry it over.
+	if _, err := tools.CopyPrefix("bucket", "src/", "bucket", "dst/", awstools.WithSkipExisting()); err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata("dst/big"); got["owner"] != "ana" || got["awstools-source-etag"] == "" {
+		t.Errorf("Expected the source ETag to be recorded, got %v", got)
+	}
+	if _, err := tools.CopyObject("bucket", "dst/big", "bucket", "again"); err != nil {
+		t.Fatal(err)
+	}
+	if got := metadata("again"); !maps.Equal(got, map[string]string{"owner": "ana"}) {
+		t.Errorf("Expected the recorded ETag not to be carried over, got %v", got)
+	}
+}
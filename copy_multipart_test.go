@@ -0,0 +1,37 @@
+package awstools
+
+import "testing"
+
+func TestPlanCopyParts(t *testing.T) {
+	const mib = 1024 * 1024
+
+	parts := planCopyParts(12*mib+1, 5*mib)
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+
+	expected := []copyPart{
+		{number: 1, start: 0, end: 5*mib - 1},
+		{number: 2, start: 5 * mib, end: 10*mib - 1},
+		{number: 3, start: 10 * mib, end: 12 * mib},
+	}
+	for i, part := range parts {
+		if part != expected[i] {
+			t.Errorf("Part %d: expected %+v, got %+v", i, expected[i], part)
+		}
+	}
+}
+
+func TestPlanCopyPartsRespectsMaxParts(t *testing.T) {
+	const size = 5 * 1024 * 1024 * 1024 * 1024 // 5 TiB
+
+	parts := planCopyParts(size, 256*1024*1024)
+	if len(parts) > maxParts {
+		t.Fatalf("Expected at most %d parts, got %d", maxParts, len(parts))
+	}
+
+	last := parts[len(parts)-1]
+	if last.end != size-1 {
+		t.Errorf("Expected last part to end at %d, got %d", int64(size-1), last.end)
+	}
+}
@@ -0,0 +1,61 @@
+package awstools
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestCopySource(t *testing.T) {
+	cases := map[string]string{
+		"plain.txt":               "bucket/plain.txt",
+		"dir/sub dir/file.txt":    "bucket/dir/sub%20dir/file.txt",
+		"a+b=c&d?.txt":            "bucket/a%2Bb%3Dc%26d%3F.txt",
+		"relatório/ção.csv":       "bucket/relat%C3%B3rio/%C3%A7%C3%A3o.csv",
+		"percent%20already.txt":   "bucket/percent%2520already.txt",
+		"tilde~under_score-.data": "bucket/tilde~under_score-.data",
+	}
+
+	for key, expected := range cases {
+		if got := copySource("bucket", key); got != expected {
+			t.Errorf("copySource(%q): expected %s, got %s", key, expected, got)
+		}
+	}
+}
+
+func TestCopyOptions(t *testing.T) {
+	input := newCopyParams(&s3.CopyObjectInput{},
+		WithCopyMetadata(map[string]string{"foo": "bar"}),
+		WithCopyContentType("application/json"),
+		WithCopyACL(types.ObjectCannedACLPrivate),
+		WithCopyStorageClass(types.StorageClassGlacierIr),
+	).input
+
+	if input.MetadataDirective != types.MetadataDirectiveReplace {
+		t.Errorf("Expected REPLACE directive, got %s", input.MetadataDirective)
+	}
+	if input.Metadata["foo"] != "bar" {
+		t.Errorf("Expected metadata foo=bar, got %v", input.Metadata)
+	}
+	if ct := aws.ToString(input.ContentType); ct != "application/json" {
+		t.Errorf("Expected content type application/json, got %s", ct)
+	}
+	if input.ACL != types.ObjectCannedACLPrivate {
+		t.Errorf("Expected ACL private, got %s", input.ACL)
+	}
+	if input.StorageClass != types.StorageClassGlacierIr {
+		t.Errorf("Expected storage class GLACIER_IR, got %s", input.StorageClass)
+	}
+}
+
+func TestCopyObjectToNilDestination(t *testing.T) {
+	a := &AWSTools{}
+	if _, err := a.CopyObjectTo(nil, "bucket", "key", "bucket", "copy"); err == nil {
+		t.Error("Expected an error copying to a nil destination")
+	}
+	if _, err := a.MoveObjectTo(nil, "bucket", "key", "bucket", "moved"); err == nil {
+		t.Error("Expected an error moving to a nil destination")
+	}
+}
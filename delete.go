@@ -0,0 +1,254 @@
+package awstools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxDeleteBatch is the maximum number of keys accepted by a single
+// DeleteObjects request.
+const maxDeleteBatch = 1000
+
+// DeleteError describes a key that S3 refused to delete in a batch request.
+type DeleteError struct {
+	Key       string
+	VersionID string
+	Code      string
+	Message   string
+}
+
+func (e DeleteError) Error() string {
+	return fmt.Sprintf("unable to delete object %q: %s: %s", e.Key, e.Code, e.Message)
+}
+
+// DeleteResult reports the outcome of a batch delete. In dry-run mode Deleted
+// lists the keys that would have been removed.
+type DeleteResult struct {
+	Deleted []string
+	Errors  []DeleteError
+	DryRun  bool
+}
+
+// DeleteOption customizes DeleteObjects and DeletePrefix calls.
+type DeleteOption func(*deleteParams)
+
+type deleteParams struct {
+	dryRun      bool
+	concurrency int
+}
+
+func (a *AWSTools) newDeleteParams(opts ...DeleteOption) *deleteParams {
+	p := &deleteParams{
+		concurrency: a.queueWorkers,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// WithDeleteDryRun lists the keys that would be deleted without deleting them.
+func WithDeleteDryRun() DeleteOption {
+	return func(p *deleteParams) {
+		p.dryRun = true
+	}
+}
+
+// WithDeleteConcurrency sets how many DeleteObjects requests run in parallel.
+// It defaults to the number of stream workers configured on AWSTools.
+func WithDeleteConcurrency(n int) DeleteOption {
+	return func(p *deleteParams) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+func (a *AWSTools) DeleteObjects(bucket string, keys []string, opts ...DeleteOption) (*DeleteResult, error) {
+	return a.DeleteObjectsWithContext(context.Background(), bucket, keys, opts...)
+}
+
+// DeleteObjectsWithContext deletes keys from bucket using the multi-object
+// delete API, in batches of up to 1000 keys. Keys S3 refused to delete are
+// reported in the result and cause a non-nil error.
+func (a *AWSTools) DeleteObjectsWithContext(ctx context.Context, bucket string, keys []string, opts ...DeleteOption) (*DeleteResult, error) {
+	params := a.newDeleteParams(opts...)
+
+	return a.runDeleteBatches(ctx, bucket, params, func(ctx context.Context, batches chan<- []types.ObjectIdentifier) error {
+		for _, chunk := range chunkKeys(keys, maxDeleteBatch) {
+			ids := make([]types.ObjectIdentifier, len(chunk))
+			for i, key := range chunk {
+				ids[i] = types.ObjectIdentifier{Key: aws.String(key)}
+			}
+			select {
+			case batches <- ids:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+func (a *AWSTools) DeletePrefix(bucket, prefix string, opts ...DeleteOption) (*DeleteResult, error) {
+	return a.DeletePrefixWithContext(context.Background(), bucket, prefix, opts...)
+}
+
+// DeletePrefixWithContext deletes every object whose key starts with prefix,
+// deleting each listed page while the next one is fetched.
+func (a *AWSTools) DeletePrefixWithContext(ctx context.Context, bucket, prefix string, opts ...DeleteOption) (*DeleteResult, error) {
+	params := a.newDeleteParams(opts...)
+
+	return a.runDeleteBatches(ctx, bucket, params, func(ctx context.Context, batches chan<- []types.ObjectIdentifier) error {
+		paginator := s3.NewListObjectsV2Paginator(a.s3Client, &s3.ListObjectsV2Input{
+			Bucket:  aws.String(bucket),
+			Prefix:  aws.String(prefix),
+			MaxKeys: aws.Int32(maxDeleteBatch),
+		})
+
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("unable to list items in bucket %q, %w", bucket, newOpError("ListObjectsV2", bucket, prefix, err))
+			}
+			if len(page.Contents) == 0 {
+				continue
+			}
+
+			ids := make([]types.ObjectIdentifier, len(page.Contents))
+			for i, obj := range page.Contents {
+				ids[i] = types.ObjectIdentifier{Key: obj.Key}
+			}
+			select {
+			case batches <- ids:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+// runDeleteBatches feeds the batches produced by produce to a pool of
+// workers issuing DeleteObjects requests. The first request-level failure
+// cancels the remaining work.
+func (a *AWSTools) runDeleteBatches(ctx context.Context, bucket string, params *deleteParams,
+	produce func(ctx context.Context, batches chan<- []types.ObjectIdentifier) error) (*DeleteResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &DeleteResult{DryRun: params.dryRun}
+	batches := make(chan []types.ObjectIdentifier)
+
+	mu := &sync.Mutex{}
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < params.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ids := range batches {
+				if params.dryRun {
+					mu.Lock()
+					for _, id := range ids {
+						result.Deleted = append(result.Deleted, aws.ToString(id.Key))
+					}
+					mu.Unlock()
+					continue
+				}
+
+				deleted, errs, err := a.deleteBatch(ctx, bucket, ids)
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				result.Deleted = append(result.Deleted, deleted...)
+				result.Errors = append(result.Errors, errs...)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	produceErr := produce(ctx, batches)
+	close(batches)
+	wg.Wait()
+
+	if firstErr != nil {
+		return result, firstErr
+	}
+	if produceErr != nil {
+		return result, produceErr
+	}
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("unable to delete %d objects from bucket %q, first: %w",
+			len(result.Errors), bucket, result.Errors[0])
+	}
+
+	return result, nil
+}
+
+// deleteBatch issues a single quiet DeleteObjects request. In quiet mode S3
+// only reports failures, so every identifier not listed as an error counts
+// as deleted. An error may carry a version for a key requested without one.
+func (a *AWSTools) deleteBatch(ctx context.Context, bucket string, ids []types.ObjectIdentifier) ([]string, []DeleteError, error) {
+	out, err := a.s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(bucket),
+		Delete: &types.Delete{Objects: ids, Quiet: aws.Bool(true)},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to delete objects from bucket %q, %w", bucket, newOpError("DeleteObjects", bucket, "", err))
+	}
+
+	failed := make(map[string]bool, len(out.Errors))
+	errs := make([]DeleteError, 0, len(out.Errors))
+	for _, e := range out.Errors {
+		failed[aws.ToString(e.Key)+"\x00"] = true
+		failed[aws.ToString(e.Key)+"\x00"+aws.ToString(e.VersionId)] = true
+		errs = append(errs, DeleteError{
+			Key:       aws.ToString(e.Key),
+			VersionID: aws.ToString(e.VersionId),
+			Code:      aws.ToString(e.Code),
+			Message:   aws.ToString(e.Message),
+		})
+	}
+
+	deleted := make([]string, 0, len(ids)-len(errs))
+	for _, id := range ids {
+		if !failed[aws.ToString(id.Key)+"\x00"+aws.ToString(id.VersionId)] {
+			deleted = append(deleted, aws.ToString(id.Key))
+		}
+	}
+
+	return deleted, errs, nil
+}
+
+// chunkKeys splits keys into slices of at most size elements.
+func chunkKeys(keys []string, size int) [][]string {
+	var chunks [][]string
+	for len(keys) > size {
+		chunks = append(chunks, keys[:size])
+		keys = keys[size:]
+	}
+	if len(keys) > 0 {
+		chunks = append(chunks, keys)
+	}
+	return chunks
+}
@@ -0,0 +1,168 @@
+package awstools_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+// deleteRecorder proxies a Server, recording the body of every
+// DeleteObjects request. When reply is set it answers those requests itself
+// instead of forwarding them.
+type deleteRecorder struct {
+	mu     sync.Mutex
+	bodies []string
+	reply  string
+}
+
+func (r *deleteRecorder) batchSizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.bodies))
+	for i, body := range r.bodies {
+		sizes[i] = strings.Count(body, "<Object>")
+	}
+	slices.Sort(sizes)
+	return sizes
+}
+
+func newDeleteTestTools(t *testing.T) (*awstools.AWSTools, *awstoolstest.Server, *deleteRecorder) {
+	t.Helper()
+	srv := awstoolstest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.CreateBucket("bucket")
+
+	rec := &deleteRecorder{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Query().Has("delete") {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			rec.mu.Lock()
+			rec.bodies = append(rec.bodies, string(body))
+			reply := rec.reply
+			rec.mu.Unlock()
+			if reply != "" {
+				w.Header().Set("Content-Type", "application/xml")
+				io.WriteString(w, reply)
+				return
+			}
+			r.Body = io.NopCloser(strings.NewReader(string(body)))
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	tools, err := awstools.NewAWSTools(append(srv.Options(), awstools.WithEndpoint(proxy.URL))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tools, srv, rec
+}
+
+func TestDeleteObjectsBatches(t *testing.T) {
+	tools, srv, rec := newDeleteTestTools(t)
+	keys := make([]string, 2500)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%04d", i)
+		srv.PutObject("bucket", keys[i], []byte("x"))
+	}
+
+	result, err := tools.DeleteObjects("bucket", keys, awstools.WithDeleteConcurrency(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.batchSizes(); !slices.Equal(got, []int{500, 1000, 1000}) {
+		t.Errorf("Expected batches of 1000, 1000 and 500 keys, got %v", got)
+	}
+	slices.Sort(result.Deleted)
+	if !slices.Equal(result.Deleted, keys) || len(result.Errors) != 0 {
+		t.Errorf("Expected every key to be deleted, got %d deleted and %v", len(result.Deleted), result.Errors)
+	}
+	if left := srv.Keys("bucket"); len(left) != 0 {
+		t.Errorf("Expected an empty bucket, %d keys left", len(left))
+	}
+}
+
+func TestDeletePrefixBatches(t *testing.T) {
+	tools, srv, rec := newDeleteTestTools(t)
+	for i := range 1500 {
+		srv.PutObject("bucket", fmt.Sprintf("logs/%04d", i), []byte("x"))
+	}
+	srv.PutObject("bucket", "keep/a", []byte("x"))
+
+	result, err := tools.DeletePrefix("bucket", "logs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.batchSizes(); !slices.Equal(got, []int{500, 1000}) {
+		t.Errorf("Expected batches of 1000 and 500 keys, got %v", got)
+	}
+	if len(result.Deleted) != 1500 {
+		t.Errorf("Expected 1500 deleted keys, got %d", len(result.Deleted))
+	}
+	if left := srv.Keys("bucket"); !slices.Equal(left, []string{"keep/a"}) {
+		t.Errorf("Expected only keep/a to be left, got %v", left)
+	}
+}
+
+// TestDeleteObjectsQuiet checks that the quiet requests, whose responses
+// list no deleted keys, still report every key that did not fail.
+func TestDeleteObjectsQuiet(t *testing.T) {
+	tools, srv, rec := newDeleteTestTools(t)
+	srv.PutObject("bucket", "a", []byte("x"))
+	srv.PutObject("bucket", "b", []byte("x"))
+
+	rec.reply = `<DeleteResult></DeleteResult>`
+	result, err := tools.DeleteObjects("bucket", []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.bodies) != 1 || !strings.Contains(rec.bodies[0], "<Quiet>true</Quiet>") {
+		t.Errorf("Expected a single quiet request, got %q", rec.bodies)
+	}
+	slices.Sort(result.Deleted)
+	if !slices.Equal(result.Deleted, []string{"a", "b"}) {
+		t.Errorf("Expected a and b to be reported as deleted, got %v", result.Deleted)
+	}
+}
+
+func TestDeleteObjectsKeyErrors(t *testing.T) {
+	tools, _, rec := newDeleteTestTools(t)
+	rec.reply = `<DeleteResult>` +
+		`<Error><Key>b</Key><Code>AccessDenied</Code><Message>Access Denied</Message></Error>` +
+		`<Error><Key>d</Key><VersionId>v1</VersionId><Code>InternalError</Code><Message>Try again</Message></Error>` +
+		`</DeleteResult>`
+
+	result, err := tools.DeleteObjects("bucket", []string{"a", "b", "c", "d"})
+	var deleteErr awstools.DeleteError
+	if !errors.As(err, &deleteErr) || deleteErr.Key != "b" {
+		t.Fatalf("Expected the first DeleteError to be wrapped, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected a result alongside the error")
+	}
+
+	slices.Sort(result.Deleted)
+	if !slices.Equal(result.Deleted, []string{"a", "c"}) {
+		t.Errorf("Expected a and c to be deleted, got %v", result.Deleted)
+	}
+	want := []awstools.DeleteError{
+		{Key: "b", Code: "AccessDenied", Message: "Access Denied"},
+		{Key: "d", VersionID: "v1", Code: "InternalError", Message: "Try again"},
+	}
+	if !slices.Equal(result.Errors, want) {
+		t.Errorf("Expected errors %+v, got %+v", want, result.Errors)
+	}
+}
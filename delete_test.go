@@ -0,0 +1,64 @@
+package awstools
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestChunkKeys(t *testing.T) {
+	keys := make([]string, 2500)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%04d", i)
+	}
+
+	chunks := chunkKeys(keys, maxDeleteBatch)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected 3 chunks, got %d", len(chunks))
+	}
+	if len(chunks[0]) != 1000 || len(chunks[1]) != 1000 || len(chunks[2]) != 500 {
+		t.Errorf("Unexpected chunk sizes: %d, %d, %d", len(chunks[0]), len(chunks[1]), len(chunks[2]))
+	}
+
+	if chunks := chunkKeys(nil, maxDeleteBatch); len(chunks) != 0 {
+		t.Errorf("Expected no chunks for empty input, got %d", len(chunks))
+	}
+}
+
+func TestDeleteObjectsDryRun(t *testing.T) {
+	tools, err := NewAWSTools(
+		WithAccessKeyID("test-key"),
+		WithSecretKey("test-secret"),
+		WithRegion("us-east-1"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create AWSTools: %v", err)
+	}
+
+	keys := make([]string, 2500)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%04d", i)
+	}
+
+	result, err := tools.DeleteObjects("bucket", keys, WithDeleteDryRun(), WithDeleteConcurrency(3))
+	if err != nil {
+		t.Fatalf("DeleteObjects dry run failed: %v", err)
+	}
+
+	if !result.DryRun {
+		t.Error("Expected DryRun to be set")
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("Expected no errors, got %v", result.Errors)
+	}
+
+	sort.Strings(result.Deleted)
+	if len(result.Deleted) != len(keys) {
+		t.Fatalf("Expected %d keys, got %d", len(keys), len(result.Deleted))
+	}
+	for i := range keys {
+		if result.Deleted[i] != keys[i] {
+			t.Fatalf("Expected key %s at %d, got %s", keys[i], i, result.Deleted[i])
+		}
+	}
+}
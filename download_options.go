@@ -0,0 +1,107 @@
+package awstools
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// DownloadOption customizes downloads and stream reads. Most options set
+// fields of the S3 GetObjectInput; the rest configure how the object is read.
+type DownloadOption func(*downloadParams)
+
+type downloadParams struct {
+	input          *s3.GetObjectInput
+	progress       ProgressFunc
+	partSize       int64
+	concurrency    int
+	bufferProvider manager.WriterReadFromProvider
+	readAhead      int64
+	// err records an invalid option, returned before any request is made.
+	err error
+}
+
+// newDownloadParams applies opts to input, which already names the object.
+func newDownloadParams(input *s3.GetObjectInput, opts ...DownloadOption) *downloadParams {
+	p := &downloadParams{input: input}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// WithDownloadVersion reads the given version of the object instead of the
+// current one.
+func WithDownloadVersion(versionID string) DownloadOption {
+	return func(p *downloadParams) {
+		p.input.VersionId = aws.String(versionID)
+	}
+}
+
+// WithDownloadSSECustomerKey supplies the customer provided key (SSE-C) the
+// object was encrypted with. The download fails with
+// ErrInvalidSSECustomerKey if key is not 32 bytes long.
+func WithDownloadSSECustomerKey(key []byte) DownloadOption {
+	return func(p *downloadParams) {
+		if err := checkSSECustomerKey(key); err != nil {
+			p.err = err
+			return
+		}
+		sse := newSSECustomerKey(key)
+		p.input.SSECustomerAlgorithm = sse.algorithm
+		p.input.SSECustomerKey = sse.key
+		p.input.SSECustomerKeyMD5 = sse.keyMD5
+	}
+}
+
+// WithDownloadChecksumValidation verifies the content read against the
+// object checksums: the SHA256 stored by WithUploadSHA256Metadata and the
+// native S3 checksum, part by part for multipart objects. The download fails
+// with ErrChecksumMismatch when they differ, or when the object has none.
+func WithDownloadChecksumValidation() DownloadOption {
+	return func(p *downloadParams) {
+		p.input.ChecksumMode = types.ChecksumModeEnabled
+	}
+}
+
+// WithDownloadProgress reports the progress of the download or stream read
+// to fn.
+func WithDownloadProgress(fn ProgressFunc) DownloadOption {
+	return func(p *downloadParams) {
+		p.progress = fn
+	}
+}
+
+// WithDownloadPartSize overrides the size of the byte ranges fetched in
+// parallel for this download.
+func WithDownloadPartSize(partSize int64) DownloadOption {
+	return func(p *downloadParams) {
+		p.partSize = partSize
+	}
+}
+
+// WithDownloadConcurrency overrides how many ranges of this download are
+// fetched in parallel.
+func WithDownloadConcurrency(concurrency int) DownloadOption {
+	return func(p *downloadParams) {
+		p.concurrency = concurrency
+	}
+}
+
+// WithDownloadBufferProvider overrides the buffer provider of this download.
+func WithDownloadBufferProvider(provider manager.WriterReadFromProvider) DownloadOption {
+	return func(p *downloadParams) {
+		p.bufferProvider = provider
+	}
+}
+
+// WithDownloadReadAhead sets the minimum number of bytes an ObjectReader
+// fetches per request. It defaults to 1 MiB.
+func WithDownloadReadAhead(size int64) DownloadOption {
+	return func(p *downloadParams) {
+		p.readAhead = size
+	}
+}
@@ -0,0 +1,413 @@
+package awstools
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// cseContentAlgorithm names the content format: AES-256-GCM over
+	// segments of cseSegmentSize bytes, each with its own tag.
+	cseContentAlgorithm = "AES256-GCM-SEG64K"
+	cseSegmentSize      = 64 * 1024
+	cseDataKeySize      = 32
+	cseNonceSize        = 12
+	cseTagSize          = 16
+
+	// Metadata keys, without the x-amz-meta- prefix, describing the envelope.
+	metaCSEKey       = "awstools-cse-key"
+	metaCSEIV        = "awstools-cse-iv"
+	metaCSEAlgorithm = "awstools-cse-alg"
+	metaCSEWrap      = "awstools-cse-wrap"
+	metaCSESize      = "awstools-cse-size"
+)
+
+// ErrNotEncrypted is returned when reading, with client-side encryption
+// configured, an object that was not uploaded with it.
+var ErrNotEncrypted = errors.New("object is not client-side encrypted")
+
+// KeyWrapper protects the data keys used by client-side encryption. Every
+// object is encrypted with its own random data key, which is stored wrapped
+// in the object metadata.
+type KeyWrapper interface {
+	// Algorithm identifies the wrapping scheme. It is stored with the object
+	// and checked before unwrapping.
+	Algorithm() string
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+type localKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper wraps data keys with AES-GCM under kek, a 16, 24 or 32
+// byte key encryption key held by the application.
+func NewLocalKeyWrapper(kek []byte) (KeyWrapper, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key, %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &localKeyWrapper{aead: aead}, nil
+}
+
+func (w *localKeyWrapper) Algorithm() string {
+	return "AES-GCM"
+}
+
+func (w *localKeyWrapper) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, w.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return w.aead.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (w *localKeyWrapper) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < w.aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, sealed := wrapped[:w.aead.NonceSize()], wrapped[w.aead.NonceSize():]
+	return w.aead.Open(nil, nonce, sealed, nil)
+}
+
+// KMSClient is the part of a KMS service used to wrap data keys. A thin
+// adapter over the Encrypt and Decrypt calls of the AWS KMS client, or of any
+// compatible service, satisfies it.
+type KMSClient interface {
+	Encrypt(ctx context.Context, keyID string, plaintext []byte, encryptionContext map[string]string) ([]byte, error)
+	Decrypt(ctx context.Context, keyID string, ciphertext []byte, encryptionContext map[string]string) ([]byte, error)
+}
+
+type kmsKeyWrapper struct {
+	client            KMSClient
+	keyID             string
+	encryptionContext map[string]string
+}
+
+// NewKMSKeyWrapper wraps data keys with the KMS key keyID. The same
+// encryptionContext must be given to unwrap them.
+func NewKMSKeyWrapper(client KMSClient, keyID string, encryptionContext map[string]string) KeyWrapper {
+	return &kmsKeyWrapper{client: client, keyID: keyID, encryptionContext: encryptionContext}
+}
+
+func (w *kmsKeyWrapper) Algorithm() string {
+	return "kms"
+}
+
+func (w *kmsKeyWrapper) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return w.client.Encrypt(ctx, w.keyID, dataKey, w.encryptionContext)
+}
+
+func (w *kmsKeyWrapper) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return w.client.Decrypt(ctx, w.keyID, wrapped, w.encryptionContext)
+}
+
+// envelope is the per-object data key and base nonce.
+type envelope struct {
+	dataKey []byte
+	iv      []byte
+}
+
+func newEnvelope() (*envelope, error) {
+	env := &envelope{
+		dataKey: make([]byte, cseDataKeySize),
+		iv:      make([]byte, cseNonceSize),
+	}
+	if _, err := rand.Read(env.dataKey); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(env.iv); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// seal wraps the data key and returns the metadata describing the envelope.
+// size is the plaintext length, or -1 when unknown.
+func (env *envelope) seal(ctx context.Context, wrapper KeyWrapper, size int64) (map[string]string, error) {
+	wrapped, err := wrapper.WrapKey(ctx, env.dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to wrap data key, %w", err)
+	}
+
+	metadata := map[string]string{
+		metaCSEKey:       base64.StdEncoding.EncodeToString(wrapped),
+		metaCSEIV:        base64.StdEncoding.EncodeToString(env.iv),
+		metaCSEAlgorithm: cseContentAlgorithm,
+		metaCSEWrap:      wrapper.Algorithm(),
+	}
+	if size >= 0 {
+		metadata[metaCSESize] = strconv.FormatInt(size, 10)
+	}
+	return metadata, nil
+}
+
+// isEncrypted reports whether metadata describes a client-side envelope.
+func isEncrypted(metadata map[string]string) bool {
+	_, ok := metadata[metaCSEKey]
+	return ok
+}
+
+// withEnvelope returns metadata with the client-side envelope of src added,
+// so that a copy of an encrypted object made with replaced metadata can
+// still be decrypted. metadata itself is not modified.
+func withEnvelope(metadata, src map[string]string) map[string]string {
+	if !isEncrypted(src) {
+		return metadata
+	}
+
+	merged := make(map[string]string, len(metadata)+5)
+	maps.Copy(merged, metadata)
+	for _, k := range []string{metaCSEKey, metaCSEIV, metaCSEAlgorithm, metaCSEWrap, metaCSESize} {
+		if v, ok := src[k]; ok {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// withoutEnvelope returns metadata without the keys of a client-side
+// envelope. metadata itself is not modified.
+func withoutEnvelope(metadata map[string]string) map[string]string {
+	if !isEncrypted(metadata) {
+		return metadata
+	}
+
+	stripped := maps.Clone(metadata)
+	for _, k := range []string{metaCSEKey, metaCSEIV, metaCSEAlgorithm, metaCSEWrap, metaCSESize} {
+		delete(stripped, k)
+	}
+	return stripped
+}
+
+// openEnvelope unwraps the data key described by metadata.
+func openEnvelope(ctx context.Context, wrapper KeyWrapper, metadata map[string]string) (*envelope, error) {
+	if !isEncrypted(metadata) {
+		return nil, ErrNotEncrypted
+	}
+	if alg := metadata[metaCSEAlgorithm]; alg != cseContentAlgorithm {
+		return nil, fmt.Errorf("unsupported client-side encryption algorithm %q", alg)
+	}
+	if wrap := metadata[metaCSEWrap]; wrap != wrapper.Algorithm() {
+		return nil, fmt.Errorf("data key was wrapped with %q, configured key wrapper uses %q", wrap, wrapper.Algorithm())
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(metadata[metaCSEKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped data key, %w", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(metadata[metaCSEIV])
+	if err != nil || len(iv) != cseNonceSize {
+		return nil, errors.New("invalid client-side encryption IV")
+	}
+
+	dataKey, err := wrapper.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key, %w", err)
+	}
+
+	return &envelope{dataKey: dataKey, iv: iv}, nil
+}
+
+// segmentCipher encrypts or decrypts a stream of GCM segments. Each segment
+// uses the base IV with its index XORed into the last 8 bytes, and is
+// authenticated together with a flag marking the final segment, so that
+// reordered, dropped or truncated segments fail to decrypt.
+type segmentCipher struct {
+	aead    cipher.AEAD
+	iv      []byte
+	src     *bufio.Reader
+	encrypt bool
+	index   uint64
+	in      []byte
+	buf     []byte
+	out     []byte // unread part of buf
+	done    bool
+	err     error
+}
+
+func newSegmentCipher(env *envelope, src io.Reader, encrypt bool) (*segmentCipher, error) {
+	block, err := aes.NewCipher(env.dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key, %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	inSize := cseSegmentSize
+	if !encrypt {
+		inSize += cseTagSize
+	}
+
+	return &segmentCipher{
+		aead:    aead,
+		iv:      env.iv,
+		src:     bufio.NewReaderSize(src, inSize),
+		encrypt: encrypt,
+		in:      make([]byte, inSize),
+	}, nil
+}
+
+// newEncryptingReader returns a reader of the ciphertext of src.
+func newEncryptingReader(env *envelope, src io.Reader) (io.Reader, error) {
+	return newSegmentCipher(env, src, true)
+}
+
+// newDecryptingReader returns a reader of the plaintext of src. Read fails
+// as soon as a segment does not authenticate.
+func newDecryptingReader(env *envelope, src io.Reader) (io.Reader, error) {
+	return newSegmentCipher(env, src, false)
+}
+
+func (c *segmentCipher) Read(p []byte) (int, error) {
+	for len(c.out) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		if c.done {
+			return 0, io.EOF
+		}
+		c.err = c.next()
+	}
+
+	n := copy(p, c.out)
+	c.out = c.out[n:]
+	return n, nil
+}
+
+// next processes one segment into c.out.
+func (c *segmentCipher) next() error {
+	n, err := io.ReadFull(c.src, c.in)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		c.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := c.src.Peek(1); err == io.EOF {
+			c.done = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	nonce := make([]byte, cseNonceSize)
+	copy(nonce, c.iv)
+	var index [8]byte
+	binary.BigEndian.PutUint64(index[:], c.index)
+	for i := range index {
+		nonce[cseNonceSize-8+i] ^= index[i]
+	}
+	c.index++
+
+	aad := []byte{0}
+	if c.done {
+		aad[0] = 1
+	}
+
+	if c.encrypt {
+		c.buf = c.aead.Seal(c.buf[:0], nonce, c.in[:n], aad)
+		c.out = c.buf
+		return nil
+	}
+
+	if n < cseTagSize {
+		return errors.New("client-side encrypted object is truncated")
+	}
+	buf, err := c.aead.Open(c.buf[:0], nonce, c.in[:n], aad)
+	if err != nil {
+		return fmt.Errorf("unable to decrypt segment %d, %w", c.index-1, err)
+	}
+	c.buf, c.out = buf, buf
+	return nil
+}
+
+// encryptUpload replaces the body of input with its ciphertext and adds the
+// envelope to the object metadata. size is the plaintext length, or -1.
+func encryptUpload(ctx context.Context, wrapper KeyWrapper, input *s3.PutObjectInput, size int64) error {
+	env, err := newEnvelope()
+	if err != nil {
+		return err
+	}
+
+	metadata, err := env.seal(ctx, wrapper, size)
+	if err != nil {
+		return err
+	}
+	for k, v := range input.Metadata {
+		if _, reserved := metadata[k]; !reserved {
+			metadata[k] = v
+		}
+	}
+
+	body, err := newEncryptingReader(env, input.Body)
+	if err != nil {
+		return err
+	}
+
+	input.Body = body
+	input.Metadata = metadata
+	input.ContentLength = nil
+	input.ContentMD5 = nil
+	return nil
+}
+
+// decryptBody returns the plaintext reader of an object body read with
+// GetObject. Without a wrapper, objects without an envelope are returned
+// unchanged and encrypted objects are an error; with one, objects without an
+// envelope fail with ErrNotEncrypted.
+func decryptBody(ctx context.Context, wrapper KeyWrapper, out *s3.GetObjectOutput) (io.Reader, error) {
+	if !isEncrypted(out.Metadata) {
+		if wrapper != nil {
+			return nil, ErrNotEncrypted
+		}
+		return out.Body, nil
+	}
+	if wrapper == nil {
+		return nil, errors.New("object is client-side encrypted, configure WithClientEncryption to read it")
+	}
+
+	env, err := openEnvelope(ctx, wrapper, out.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptingReader(env, out.Body)
+}
+
+// plaintextBody returns the content of an object read with GetObject,
+// decrypted with the client-side encryption configured on a.
+func (a *AWSTools) plaintextBody(ctx context.Context, out *s3.GetObjectOutput) (io.Reader, error) {
+	if a.params.UnencryptedReads() && !isEncrypted(out.Metadata) {
+		return out.Body, nil
+	}
+	return decryptBody(ctx, a.params.ClientEncryption(), out)
+}
+
+// checkEnvelope applies the rule of plaintextBody to reads that cannot
+// decrypt: with client-side encryption configured, an object without an
+// envelope fails with ErrNotEncrypted unless WithUnencryptedReads is set.
+func (a *AWSTools) checkEnvelope(metadata map[string]string) error {
+	if a.params.ClientEncryption() != nil && !isEncrypted(metadata) && !a.params.UnencryptedReads() {
+		return ErrNotEncrypted
+	}
+	return nil
+}
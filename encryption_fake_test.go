@@ -0,0 +1,204 @@
+package awstools_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+func newEncryptedTestTools(t *testing.T, opts ...awstools.Options) (*awstools.AWSTools, *awstoolstest.Server) {
+	t.Helper()
+	wrapper, err := awstools.NewLocalKeyWrapper(bytes.Repeat([]byte{0x11}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts = append([]awstools.Options{
+		awstools.WithClientEncryption(wrapper),
+		awstools.WithMultipartCopyThreshold(6 * 1024 * 1024),
+		awstools.WithMultipartCopyPartSize(5 * 1024 * 1024),
+	}, opts...)
+	tools, srv := awstoolstest.New(t, opts...)
+	srv.CreateBucket("bucket")
+	return tools, srv
+}
+
+func readStoreObject(t *testing.T, store awstools.ObjectStore, key string) []byte {
+	t.Helper()
+	r, _, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get %s: %v", key, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Read %s: %v", key, err)
+	}
+	return data
+}
+
+func TestCopyReplaceKeepsEnvelope(t *testing.T) {
+	ctx := context.Background()
+	tools, _ := newEncryptedTestTools(t)
+	store := tools.Store("bucket", "")
+
+	small := []byte("secret")
+	big := bytes.Repeat([]byte("s"), 7*1024*1024)
+	for key, data := range map[string][]byte{"small": small, "big": big} {
+		if err := store.Put(ctx, key, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+
+		// A single CopyObject or a multipart copy, depending on the size.
+		if _, err := tools.CopyObjectWithContext(ctx, "bucket", key, "bucket", key+"-copy",
+			awstools.WithCopyContentType("text/plain"),
+			awstools.WithCopyMetadata(map[string]string{"owner": "ana"})); err != nil {
+			t.Fatal(err)
+		}
+		if got := readStoreObject(t, store, key+"-copy"); !bytes.Equal(got, data) {
+			t.Errorf("%s: expected the copy to decrypt to the source", key)
+		}
+		info, err := tools.StatObject("bucket", key+"-copy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.ContentType != "text/plain" || info.Metadata["owner"] != "ana" {
+			t.Errorf("%s: expected the replaced metadata, got %+v", key, info)
+		}
+
+		if _, err := tools.CopyObjectToWithContext(ctx, tools, "bucket", key, "bucket", key+"-streamed",
+			awstools.WithCopyMetadata(map[string]string{"owner": "ana"})); err != nil {
+			t.Fatal(err)
+		}
+		if got := readStoreObject(t, store, key+"-streamed"); !bytes.Equal(got, data) {
+			t.Errorf("%s: expected the streamed copy to decrypt to the source", key)
+		}
+	}
+}
+
+func TestClientEncryptionRejectsUnencrypted(t *testing.T) {
+	ctx := context.Background()
+	tools, srv := newEncryptedTestTools(t)
+	srv.PutObject("bucket", "forged", []byte("forged"))
+	out := filepath.Join(t.TempDir(), "out")
+
+	if _, _, err := tools.Store("bucket", "").Get(ctx, "forged"); !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("Store.Get: expected ErrNotEncrypted, got %v", err)
+	}
+	if err := tools.DownloadFileFromS3("bucket", "forged", out); !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("DownloadFileFromS3: expected ErrNotEncrypted, got %v", err)
+	}
+	if err := tools.DownloadFileResumable("bucket", "forged", out); !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("DownloadFileResumable: expected ErrNotEncrypted, got %v", err)
+	}
+	if _, err := tools.GetObjectRange("bucket", "forged", 0, 1); !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("GetObjectRange: expected ErrNotEncrypted, got %v", err)
+	}
+	if _, err := tools.OpenObject("bucket", "forged"); !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("OpenObject: expected ErrNotEncrypted, got %v", err)
+	}
+	fsys := tools.FS("bucket", "")
+	if _, err := fs.ReadFile(fsys, "forged"); !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("fs.ReadFile: expected ErrNotEncrypted, got %v", err)
+	}
+	if _, err := fsys.Open("forged"); !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("Open: expected ErrNotEncrypted, got %v", err)
+	}
+
+	errs := tools.ReadFileStreamFromS3("bucket", "forged", func(line string) error {
+		t.Errorf("Expected no lines from an unencrypted object, got %q", line)
+		return nil
+	})
+	if err := <-errs; !errors.Is(err, awstools.ErrNotEncrypted) {
+		t.Errorf("ReadFileStreamFromS3: expected ErrNotEncrypted, got %v", err)
+	}
+}
+
+func TestClientEncryptionUnencryptedReads(t *testing.T) {
+	tools, srv := newEncryptedTestTools(t, awstools.WithUnencryptedReads())
+	store := tools.Store("bucket", "")
+	srv.PutObject("bucket", "plain", []byte("plain"))
+	if err := store.Put(context.Background(), "secret", bytes.NewReader([]byte("secret"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readStoreObject(t, store, "plain"); string(got) != "plain" {
+		t.Errorf("Expected the unencrypted object as is, got %q", got)
+	}
+	if got := readStoreObject(t, store, "secret"); string(got) != "secret" {
+		t.Errorf("Expected encrypted objects to still be decrypted, got %q", got)
+	}
+	if got, err := tools.GetObjectRange("bucket", "plain", 1, 3); err != nil || string(got) != "lai" {
+		t.Errorf("Unexpected range of the unencrypted object: %q %v", got, err)
+	}
+}
+
+func TestCopyObjectToAcrossKeyWrappers(t *testing.T) {
+	ctx := context.Background()
+	srcTools, srv := newEncryptedTestTools(t)
+	srv.PutObject("bucket", "plain", []byte("plain"))
+
+	other, err := awstools.NewLocalKeyWrapper(bytes.Repeat([]byte{0x22}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstTools, err := awstools.NewAWSTools(append(srv.Options(), awstools.WithClientEncryption(other))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainTools, err := awstools.NewAWSTools(append(srv.Options(), awstools.WithUnencryptedReads())...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := bytes.Repeat([]byte("s"), 200*1024)
+	if err := srcTools.Store("bucket", "").Put(ctx, "secret", bytes.NewReader(secret),
+		awstools.WithUploadMetadata(map[string]string{"owner": "ana"})); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to *awstools.AWSTools
+		src      string
+		want     []byte
+	}{
+		{"encrypted to other wrapper", srcTools, dstTools, "secret", secret},
+		{"plain to encrypted", plainTools, dstTools, "plain", []byte("plain")},
+		{"encrypted to plain", srcTools, plainTools, "secret", secret},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := fmt.Sprintf("copy-%d", i)
+			if _, err := tt.from.MoveObjectTo(tt.to, "bucket", tt.src, "bucket", key); err != nil {
+				t.Fatal(err)
+			}
+			if got := readStoreObject(t, tt.to.Store("bucket", ""), key); !bytes.Equal(got, tt.want) {
+				t.Error("Expected the destination to read back as the source content")
+			}
+			info, err := tt.to.Store("bucket", "").Stat(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size != int64(len(tt.want)) {
+				t.Errorf("Expected size %d, got %d", len(tt.want), info.Size)
+			}
+			if tt.src == "secret" && info.Metadata["owner"] != "ana" {
+				t.Errorf("Expected the user metadata to be kept, got %v", info.Metadata)
+			}
+
+			// Move the object back for the next case.
+			if _, err := tt.to.MoveObjectTo(tt.from, "bucket", key, "bucket", tt.src); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package awstools
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func newTestKeyWrapper(t *testing.T) KeyWrapper {
+	t.Helper()
+
+	wrapper, err := NewLocalKeyWrapper(bytes.Repeat([]byte{0x11}, 32))
+	if err != nil {
+		t.Fatalf("NewLocalKeyWrapper failed: %v", err)
+	}
+	return wrapper
+}
+
+func encryptForTest(t *testing.T, wrapper KeyWrapper, plaintext []byte) *s3.GetObjectOutput {
+	t.Helper()
+
+	input := &s3.PutObjectInput{
+		Body:     bytes.NewReader(plaintext),
+		Metadata: map[string]string{"owner": "alice"},
+	}
+	if err := encryptUpload(context.Background(), wrapper, input, int64(len(plaintext))); err != nil {
+		t.Fatalf("encryptUpload failed: %v", err)
+	}
+
+	ciphertext, err := io.ReadAll(input.Body)
+	if err != nil {
+		t.Fatalf("Encrypting failed: %v", err)
+	}
+	if bytes.Contains(ciphertext, []byte("plaintext marker")) {
+		t.Fatal("Ciphertext contains the plaintext")
+	}
+
+	return &s3.GetObjectOutput{
+		Body:     io.NopCloser(bytes.NewReader(ciphertext)),
+		Metadata: input.Metadata,
+	}
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	wrapper := newTestKeyWrapper(t)
+
+	for _, size := range []int{0, 1, cseSegmentSize - 1, cseSegmentSize, cseSegmentSize + 1, 3*cseSegmentSize + 5} {
+		plaintext := make([]byte, size)
+		if _, err := rand.Read(plaintext); err != nil {
+			t.Fatal(err)
+		}
+		copy(plaintext, "plaintext marker")
+		plaintext = plaintext[:size]
+
+		out := encryptForTest(t, wrapper, plaintext)
+		if out.Metadata["owner"] != "alice" {
+			t.Errorf("User metadata was lost: %v", out.Metadata)
+		}
+
+		body, err := decryptBody(context.Background(), wrapper, out)
+		if err != nil {
+			t.Fatalf("size %d: decryptBody failed: %v", size, err)
+		}
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("size %d: decrypting failed: %v", size, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("size %d: decrypted content differs", size)
+		}
+	}
+}
+
+func TestEncryptionDetectsTampering(t *testing.T) {
+	wrapper := newTestKeyWrapper(t)
+	plaintext := bytes.Repeat([]byte("x"), 2*cseSegmentSize+10)
+
+	tamper := map[string]func([]byte) []byte{
+		"flipped bit": func(c []byte) []byte {
+			c[10] ^= 0x01
+			return c
+		},
+		"dropped last segment": func(c []byte) []byte {
+			return c[:2*(cseSegmentSize+cseTagSize)]
+		},
+		"truncated tag": func(c []byte) []byte {
+			return c[:len(c)-4]
+		},
+	}
+
+	for name, fn := range tamper {
+		out := encryptForTest(t, wrapper, plaintext)
+		ciphertext, _ := io.ReadAll(out.Body)
+		out.Body = io.NopCloser(bytes.NewReader(fn(ciphertext)))
+
+		body, err := decryptBody(context.Background(), wrapper, out)
+		if err != nil {
+			t.Fatalf("%s: decryptBody failed: %v", name, err)
+		}
+		if _, err := io.ReadAll(body); err == nil {
+			t.Errorf("%s: expected a decryption error", name)
+		}
+	}
+}
+
+func TestDecryptBodyWithoutEnvelope(t *testing.T) {
+	out := &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("plain"))}
+
+	body, err := decryptBody(context.Background(), nil, out)
+	if err != nil {
+		t.Fatalf("decryptBody failed: %v", err)
+	}
+	if got, _ := io.ReadAll(body); string(got) != "plain" {
+		t.Errorf("Expected unencrypted body unchanged, got %q", got)
+	}
+
+	if _, err := decryptBody(context.Background(), newTestKeyWrapper(t), out); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Expected ErrNotEncrypted reading an unencrypted object with a key wrapper, got %v", err)
+	}
+
+	encrypted := encryptForTest(t, newTestKeyWrapper(t), []byte("secret"))
+	if _, err := decryptBody(context.Background(), nil, encrypted); err == nil {
+		t.Error("Expected an error reading an encrypted object without a key wrapper")
+	}
+
+	other, err := NewLocalKeyWrapper(bytes.Repeat([]byte{0x22}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptBody(context.Background(), other, encrypted); err == nil {
+		t.Error("Expected an error unwrapping with a different key")
+	}
+}
+
+type fakeKMS struct {
+	wrapper KeyWrapper
+	context map[string]string
+}
+
+func (f *fakeKMS) Encrypt(ctx context.Context, keyID string, plaintext []byte, encryptionContext map[string]string) ([]byte, error) {
+	f.context = encryptionContext
+	return f.wrapper.WrapKey(ctx, plaintext)
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, keyID string, ciphertext []byte, encryptionContext map[string]string) ([]byte, error) {
+	if encryptionContext["purpose"] != f.context["purpose"] {
+		return nil, errors.New("encryption context mismatch")
+	}
+	return f.wrapper.UnwrapKey(ctx, ciphertext)
+}
+
+func TestKMSKeyWrapper(t *testing.T) {
+	kms := &fakeKMS{wrapper: newTestKeyWrapper(t)}
+	wrapper := NewKMSKeyWrapper(kms, "alias/exports", map[string]string{"purpose": "pii"})
+
+	out := encryptForTest(t, wrapper, []byte("secret"))
+	if out.Metadata[metaCSEWrap] != "kms" {
+		t.Errorf("Expected kms wrap algorithm, got %q", out.Metadata[metaCSEWrap])
+	}
+	if out.Metadata[metaCSESize] != "6" {
+		t.Errorf("Expected plaintext size 6, got %q", out.Metadata[metaCSESize])
+	}
+
+	body, err := decryptBody(context.Background(), wrapper, out)
+	if err != nil {
+		t.Fatalf("decryptBody failed: %v", err)
+	}
+	if got, _ := io.ReadAll(body); string(got) != "secret" {
+		t.Errorf("Expected secret, got %q", got)
+	}
+
+	mismatch := NewKMSKeyWrapper(kms, "alias/exports", map[string]string{"purpose": "other"})
+	out = encryptForTest(t, wrapper, []byte("secret"))
+	if _, err := decryptBody(context.Background(), mismatch, out); err == nil {
+		t.Error("Expected an error with a different encryption context")
+	}
+}
+
+func TestClientEncryptionOption(t *testing.T) {
+	if _, err := newAWSToolsParams(WithClientEncryption(nil)); err == nil {
+		t.Error("Expected an error for a nil key wrapper")
+	}
+
+	params, err := newAWSToolsParams(WithClientEncryption(newTestKeyWrapper(t)))
+	if err != nil {
+		t.Fatalf("newAWSToolsParams failed: %v", err)
+	}
+	if params.ClientEncryption() == nil {
+		t.Error("Expected the key wrapper to be set")
+	}
+	if params.UnencryptedReads() {
+		t.Error("Expected unencrypted reads to be refused by default")
+	}
+	if params, _ := newAWSToolsParams(WithUnencryptedReads()); !params.UnencryptedReads() {
+		t.Error("Expected WithUnencryptedReads to allow unencrypted reads")
+	}
+
+	input := &s3.PutObjectInput{Body: strings.NewReader(""), ContentMD5: aws.String("x")}
+	if err := encryptUpload(context.Background(), params.ClientEncryption(), input, -1); err != nil {
+		t.Fatalf("encryptUpload failed: %v", err)
+	}
+	if input.ContentMD5 != nil {
+		t.Error("Content-MD5 of the plaintext must be dropped")
+	}
+	if _, ok := input.Metadata[metaCSESize]; ok {
+		t.Error("Unknown size must not be recorded")
+	}
+}
+
+func TestPlaintextSizeWithoutRecordedSize(t *testing.T) {
+	for _, size := range []int{0, 1, cseSegmentSize - 1, cseSegmentSize, cseSegmentSize + 1, 3*cseSegmentSize + 7} {
+		env, err := newEnvelope()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := newEncryptingReader(env, bytes.NewReader(make([]byte, size)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		info := &ObjectInfo{Size: int64(len(ciphertext)), Metadata: map[string]string{metaCSEKey: "wrapped"}}
+		if got := plaintextSize(info); got != int64(size) {
+			t.Errorf("Expected plaintext size %d for %d bytes of ciphertext, got %d", size, len(ciphertext), got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package awstools
+
+import (
+	"errors"
+	"net/http"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+)
+
+// Sentinel errors for the most common S3 failures. Errors returned by
+// AWSTools wrap an *OpError that matches one of these with errors.Is, while
+// the original SDK error stays reachable through errors.As.
+var (
+	ErrNotFound           = errors.New("awstools: not found")
+	ErrBucketNotFound     = errors.New("awstools: bucket not found")
+	ErrAccessDenied       = errors.New("awstools: access denied")
+	ErrPreconditionFailed = errors.New("awstools: precondition failed")
+	ErrThrottled          = errors.New("awstools: request throttled")
+	ErrChecksumMismatch   = errors.New("awstools: checksum mismatch")
+)
+
+// OpError describes a failed S3 operation.
+type OpError struct {
+	Op         string // S3 API operation, e.g. "GetObject"
+	Bucket     string
+	Key        string
+	StatusCode int    // HTTP status code, 0 when no response was received
+	RequestID  string // S3 request ID, empty when no response was received
+	Err        error
+
+	kind error
+}
+
+// newOpError builds an *OpError for a failed S3 call, extracting the HTTP
+// status and request ID from err and classifying it against the sentinels.
+func newOpError(op, bucket, key string, err error) *OpError {
+	e := &OpError{
+		Op:     op,
+		Bucket: bucket,
+		Key:    key,
+		Err:    err,
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		e.StatusCode = respErr.HTTPStatusCode()
+		e.RequestID = respErr.ServiceRequestID()
+	}
+
+	var code string
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code = apiErr.ErrorCode()
+	}
+
+	e.kind = classifyError(op, code, e.StatusCode)
+
+	return e
+}
+
+// Error returns the message of the underlying SDK error, which already names
+// the operation, status code and request ID.
+func (e *OpError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches one of the package sentinels.
+// ErrBucketNotFound also matches ErrNotFound.
+func (e *OpError) Is(target error) bool {
+	if e.kind == nil {
+		return false
+	}
+	if target == e.kind {
+		return true
+	}
+	return target == ErrNotFound && e.kind == ErrBucketNotFound
+}
+
+// classifyError maps an S3 error code and HTTP status to a sentinel error.
+// HEAD responses carry no body, so HeadBucket relies on the status alone.
+func classifyError(op, code string, status int) error {
+	switch code {
+	case "NoSuchBucket":
+		return ErrBucketNotFound
+	case "NoSuchKey", "NoSuchVersion", "NoSuchUpload", "NotFound":
+		if op == "HeadBucket" {
+			return ErrBucketNotFound
+		}
+		return ErrNotFound
+	case "AccessDenied", "Forbidden", "AllAccessDisabled":
+		return ErrAccessDenied
+	case "PreconditionFailed":
+		return ErrPreconditionFailed
+	case "SlowDown", "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequestsException":
+		return ErrThrottled
+	}
+
+	switch status {
+	case http.StatusNotFound:
+		if op == "HeadBucket" {
+			return ErrBucketNotFound
+		}
+		return ErrNotFound
+	case http.StatusForbidden:
+		return ErrAccessDenied
+	case http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return ErrThrottled
+	}
+
+	return nil
+}
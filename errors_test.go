@@ -0,0 +1,93 @@
+package awstools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// newTestResponseError simula o erro retornado pelo SDK para uma resposta HTTP
+func newTestResponseError(op string, status int, code string) error {
+	return &smithy.OperationError{
+		ServiceID:     "S3",
+		OperationName: op,
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+				Err:      &smithy.GenericAPIError{Code: code},
+			},
+			RequestID: "req-123",
+		},
+	}
+}
+
+func TestOpErrorClassification(t *testing.T) {
+	cases := []struct {
+		op       string
+		status   int
+		code     string
+		expected error
+	}{
+		{"GetObject", http.StatusNotFound, "NoSuchKey", ErrNotFound},
+		{"GetObject", http.StatusNotFound, "NoSuchBucket", ErrBucketNotFound},
+		{"HeadObject", http.StatusNotFound, "", ErrNotFound},
+		{"HeadBucket", http.StatusNotFound, "NotFound", ErrBucketNotFound},
+		{"PutObject", http.StatusForbidden, "AccessDenied", ErrAccessDenied},
+		{"CopyObject", http.StatusPreconditionFailed, "PreconditionFailed", ErrPreconditionFailed},
+		{"PutObject", http.StatusServiceUnavailable, "SlowDown", ErrThrottled},
+		{"PutObject", http.StatusTooManyRequests, "", ErrThrottled},
+	}
+
+	for _, c := range cases {
+		err := fmt.Errorf("wrapped, %w", newOpError(c.op, "bucket", "key", newTestResponseError(c.op, c.status, c.code)))
+
+		if !errors.Is(err, c.expected) {
+			t.Errorf("%s %d %q: expected %v, got %v", c.op, c.status, c.code, c.expected, err)
+		}
+
+		var opErr *OpError
+		if !errors.As(err, &opErr) {
+			t.Fatalf("%s: expected *OpError in chain", c.op)
+		}
+		if opErr.StatusCode != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.op, c.status, opErr.StatusCode)
+		}
+		if opErr.RequestID != "req-123" {
+			t.Errorf("%s: expected request id req-123, got %s", c.op, opErr.RequestID)
+		}
+
+		var apiErr smithy.APIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode() != c.code {
+			t.Errorf("%s: expected smithy.APIError with code %q in chain", c.op, c.code)
+		}
+	}
+}
+
+func TestOpErrorBucketNotFoundIsNotFound(t *testing.T) {
+	err := newOpError("HeadBucket", "bucket", "", newTestResponseError("HeadBucket", http.StatusNotFound, ""))
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("Expected ErrBucketNotFound to also match ErrNotFound")
+	}
+	if errors.Is(err, ErrAccessDenied) {
+		t.Error("Did not expect ErrAccessDenied")
+	}
+}
+
+func TestOpErrorUnclassified(t *testing.T) {
+	err := newOpError("PutObject", "bucket", "key", errors.New("connection reset"))
+
+	for _, sentinel := range []error{ErrNotFound, ErrBucketNotFound, ErrAccessDenied, ErrPreconditionFailed, ErrThrottled} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("Did not expect %v", sentinel)
+		}
+	}
+	if err.StatusCode != 0 || err.RequestID != "" {
+		t.Errorf("Expected no status or request id, got %d %q", err.StatusCode, err.RequestID)
+	}
+}
@@ -41,23 +41,20 @@ func main() {
 
 	// Verificar se o arquivo existe
 	log.Printf("Checking if file %q exists in bucket %q...\n", fileName, bucketName)
-	objs, err := t.ListFilesInBucketWithContext(ctx, bucketName)
+	exists, err := t.ObjectExistsWithContext(ctx, bucketName, fileName)
 	if err != nil {
-		log.Fatalf("Failed to list files: %s", err)
+		log.Fatalf("Failed to check file: %s", err)
 	}
 
-	fileExists := false
-	for _, obj := range objs {
-		if *obj.Key == fileName {
-			fileExists = true
-			log.Printf("✓ File found: %s (size: %d bytes)\n", *obj.Key, obj.Size)
-			break
-		}
+	if !exists {
+		log.Fatalf("File %q not found in bucket %q. Please run the upload example first.", fileName, bucketName)
 	}
 
-	if !fileExists {
-		log.Fatalf("File %q not found in bucket %q. Please run the upload example first.", fileName, bucketName)
+	info, err := t.StatObjectWithContext(ctx, bucketName, fileName)
+	if err != nil {
+		log.Fatalf("Failed to stat file: %s", err)
 	}
+	log.Printf("✓ File found: %s (size: %d bytes)\n", info.Key, info.Size)
 
 	fmt.Println(strings.Repeat("-", 80))
 	log.Println("Starting streaming read with parallel processing...")
@@ -0,0 +1,365 @@
+package awstools
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// localMetaSuffix is appended to the file name of an object to name the
+	// JSON sidecar holding its metadata.
+	localMetaSuffix = ".awstools-meta.json"
+	// localTempPrefix starts the names of the files being written by Put.
+	localTempPrefix = ".awstools-tmp-"
+)
+
+// LocalStore is an ObjectStore over a directory, for development and tests.
+// Keys are slash-separated paths below the directory, and each object is a
+// file with its metadata in a JSON sidecar next to it. Files without a
+// sidecar, e.g. copied in by hand, are objects without metadata.
+//
+// Keys must be valid io/fs paths, so unlike S3 they cannot start or end with
+// "/" or contain empty, "." or ".." elements, and a key cannot be both an
+// object and the prefix of another one. ETags are the hex MD5 of the
+// content, as for objects S3 received in a single part.
+//
+// An object exists once its file does. Put and Copy rename the sidecar into
+// place first and the file last, each rename being atomic, so a new object
+// appears complete with its metadata and readers never see partial content.
+// The pair is not replaced atomically, though: while an existing object is
+// overwritten, or after a crash between the two renames, the new metadata,
+// ETag included, can be read alongside the previous content.
+type LocalStore struct {
+	root string
+}
+
+// localMeta is the sidecar of an object of a LocalStore.
+type localMeta struct {
+	ETag               string            `json:"etag"`
+	ContentType        string            `json:"content_type,omitempty"`
+	ContentEncoding    string            `json:"content_encoding,omitempty"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+	ContentLanguage    string            `json:"content_language,omitempty"`
+	CacheControl       string            `json:"cache_control,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+// NewLocalStore returns the ObjectStore over the directory root, creating it
+// if needed.
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory %q, %w", root, err)
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// path returns the file of key.
+func (s *LocalStore) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." || strings.HasSuffix(key, localMetaSuffix) ||
+		strings.HasPrefix(path.Base(key), localTempPrefix) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// notFound reports a missing object with an error matching ErrNotFound.
+func (s *LocalStore) notFound(key string, err error) error {
+	return fmt.Errorf("object %q not found in %q, %w: %w", key, s.root, ErrNotFound, err)
+}
+
+// Put writes r to a temporary file and renames it into place after its
+// sidecar.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error {
+	input := applyUploadOptions(&s3.PutObjectInput{Key: aws.String(key), Body: r}, opts)
+
+	if err := fillSHA256Metadata(input); err != nil {
+		return fmt.Errorf("failed to hash object %q, %w", key, err)
+	}
+
+	metadata := make(map[string]string, len(input.Metadata))
+	for k, v := range input.Metadata {
+		metadata[strings.ToLower(k)] = v
+	}
+	meta := &localMeta{
+		ContentType:        aws.ToString(input.ContentType),
+		ContentEncoding:    aws.ToString(input.ContentEncoding),
+		ContentDisposition: aws.ToString(input.ContentDisposition),
+		ContentLanguage:    aws.ToString(input.ContentLanguage),
+		CacheControl:       aws.ToString(input.CacheControl),
+		Metadata:           metadata,
+	}
+
+	return s.write(ctx, key, input.Body, meta)
+}
+
+// write stores the content of r under key with meta, filling its ETag. The
+// sidecar is renamed first so that the file, whose presence makes the
+// object, never shows up without its metadata.
+func (s *LocalStore) write(ctx context.Context, key string, r io.Reader, meta *localMeta) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to write object %q, %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, localTempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to write object %q, %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := md5.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), contextReader{ctx, r})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write object %q, %w", key, err)
+	}
+	meta.ETag = hex.EncodeToString(h.Sum(nil))
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	sidecar := tmp.Name() + localMetaSuffix
+	if err := os.WriteFile(sidecar, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write metadata of object %q, %w", key, err)
+	}
+	defer os.Remove(sidecar)
+
+	if err := os.Rename(sidecar, name+localMetaSuffix); err != nil {
+		return fmt.Errorf("failed to write metadata of object %q, %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to write object %q, %w", key, err)
+	}
+	return nil
+}
+
+// Get opens the file of the object.
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, s.notFound(key, err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get object %q, %w", key, err)
+	}
+
+	info, err := s.stat(key, name)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+func (s *LocalStore) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.stat(key, name)
+}
+
+// stat builds the ObjectInfo of key from its file and sidecar. The ETag of
+// files without a sidecar is computed from their content.
+func (s *LocalStore) stat(key, name string) (*ObjectInfo, error) {
+	fi, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, s.notFound(key, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat object %q, %w", key, err)
+	}
+	if fi.IsDir() {
+		return nil, s.notFound(key, fs.ErrNotExist)
+	}
+
+	meta, err := readLocalMeta(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat object %q, %w", key, err)
+	}
+	if meta.ETag == "" {
+		if meta.ETag, err = fileMD5(name); err != nil {
+			return nil, fmt.Errorf("unable to stat object %q, %w", key, err)
+		}
+	}
+
+	return &ObjectInfo{
+		Key:                key,
+		Size:               fi.Size(),
+		ETag:               meta.ETag,
+		ContentType:        meta.ContentType,
+		ContentEncoding:    meta.ContentEncoding,
+		ContentDisposition: meta.ContentDisposition,
+		ContentLanguage:    meta.ContentLanguage,
+		CacheControl:       meta.CacheControl,
+		Metadata:           meta.Metadata,
+		LastModified:       fi.ModTime(),
+	}, nil
+}
+
+// readLocalMeta reads the sidecar of the file name, returning empty metadata
+// when there is none.
+func readLocalMeta(name string) (*localMeta, error) {
+	meta := &localMeta{}
+	data, err := os.ReadFile(name + localMetaSuffix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, fmt.Errorf("invalid metadata sidecar, %w", err)
+	}
+	return meta, nil
+}
+
+func fileMD5(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// List walks the directory holding prefix and yields the objects under it
+// once they are sorted by key.
+func (s *LocalStore) List(ctx context.Context, prefix string) iter.Seq2[*ObjectInfo, error] {
+	return func(yield func(*ObjectInfo, error) bool) {
+		base := prefix[:strings.LastIndex(prefix, "/")+1]
+		if base != "" && !fs.ValidPath(strings.TrimSuffix(base, "/")) {
+			yield(nil, fmt.Errorf("invalid prefix %q", prefix))
+			return
+		}
+		start := filepath.Join(s.root, filepath.FromSlash(base))
+
+		var keys []string
+		err := filepath.WalkDir(start, func(name string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && name == start {
+				return fs.SkipAll
+			}
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if d.IsDir() || strings.HasSuffix(name, localMetaSuffix) || strings.HasPrefix(d.Name(), localTempPrefix) {
+				return nil
+			}
+
+			rel, err := filepath.Rel(s.root, name)
+			if err != nil {
+				return err
+			}
+			if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+			return nil
+		})
+		if err != nil {
+			yield(nil, fmt.Errorf("unable to list objects under %q, %w", prefix, err))
+			return
+		}
+
+		sort.Strings(keys)
+		for _, key := range keys {
+			info, err := s.Stat(ctx, key)
+			if errors.Is(err, ErrNotFound) {
+				// Deleted since the walk.
+				continue
+			}
+			if !yield(info, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Delete removes the file of the object and its sidecar, then the
+// directories left empty, since S3 has no directories to keep.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range []string{name, name + localMetaSuffix} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to delete object %q, %w", key, err)
+		}
+	}
+
+	root := filepath.Clean(s.root)
+	for dir := filepath.Dir(name); dir != root; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			// Not empty, or already gone.
+			break
+		}
+	}
+	return nil
+}
+
+// Copy writes the content and metadata of srcKey under dstKey.
+func (s *LocalStore) Copy(ctx context.Context, srcKey, dstKey string) error {
+	r, info, err := s.Get(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return s.write(ctx, dstKey, r, &localMeta{
+		ContentType:        info.ContentType,
+		ContentEncoding:    info.ContentEncoding,
+		ContentDisposition: info.ContentDisposition,
+		ContentLanguage:    info.ContentLanguage,
+		CacheControl:       info.CacheControl,
+		Metadata:           info.Metadata,
+	})
+}
+
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,202 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestLocalStorePutGet(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+
+	err := store.Put(ctx, "docs/a.txt", strings.NewReader("hello"),
+		WithUploadContentType("text/plain"),
+		WithUploadCacheControl("no-cache"),
+		WithUploadMetadata(map[string]string{"Author": "ana"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, info, err := store.Get(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("Unexpected content: %q %v", data, err)
+	}
+	if info.Key != "docs/a.txt" || info.Size != 5 || info.ContentType != "text/plain" || info.CacheControl != "no-cache" {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+	if info.Metadata["author"] != "ana" {
+		t.Errorf("Expected lowercased metadata keys, got %v", info.Metadata)
+	}
+	if info.ETag != "5d41402abc4b2a76b9719d911017c592" {
+		t.Errorf("Expected the MD5 of the content as ETag, got %s", info.ETag)
+	}
+
+	// Put replaces the content and the metadata.
+	if err := store.Put(ctx, "docs/a.txt", strings.NewReader("bye")); err != nil {
+		t.Fatal(err)
+	}
+	info, err = store.Stat(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 3 || info.ContentType != "" || len(info.Metadata) != 0 {
+		t.Errorf("Expected the metadata to be replaced, got %+v", info)
+	}
+}
+
+func TestLocalStoreNotFound(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+
+	if _, _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Get, got %v", err)
+	}
+	if _, err := store.Stat(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Stat, got %v", err)
+	}
+	if err := store.Copy(ctx, "missing", "dst"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Copy, got %v", err)
+	}
+	if err := store.Delete(ctx, "missing"); err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+
+	if err := store.Put(ctx, "dir/file", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Stat(ctx, "dir"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected directories not to be objects, got %v", err)
+	}
+}
+
+func TestLocalStoreInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+
+	for _, key := range []string{"", "/abs", "../escape", "a/../b", "dir/", "a" + localMetaSuffix, localTempPrefix + "x"} {
+		if err := store.Put(ctx, key, strings.NewReader("x")); err == nil {
+			t.Errorf("Expected key %q to be rejected", key)
+		}
+	}
+	for range store.List(ctx, "../") {
+		return
+	}
+	t.Error("Expected an error listing an invalid prefix")
+}
+
+func TestLocalStoreList(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+
+	for _, key := range []string{"a/b", "a-c", "a/d/e", "b"} {
+		if err := store.Put(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(prefix string) string {
+		var keys []string
+		for info, err := range store.List(ctx, prefix) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size != int64(len(info.Key)) || info.ETag == "" || info.LastModified.IsZero() {
+				t.Errorf("Unexpected info: %+v", info)
+			}
+			keys = append(keys, info.Key)
+		}
+		return strings.Join(keys, ",")
+	}
+
+	if got := list(""); got != "a-c,a/b,a/d/e,b" {
+		t.Errorf("Expected keys in lexicographic order, got %s", got)
+	}
+	if got := list("a/"); got != "a/b,a/d/e" {
+		t.Errorf("Unexpected keys under a/: %s", got)
+	}
+	if got := list("a"); got != "a-c,a/b,a/d/e" {
+		t.Errorf("Unexpected keys under a: %s", got)
+	}
+	if got := list("missing/"); got != "" {
+		t.Errorf("Expected no keys under a missing prefix, got %s", got)
+	}
+
+	count := 0
+	for range store.List(ctx, "") {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Error("Expected the iteration to stop when the loop breaks")
+	}
+}
+
+func TestLocalStoreDeleteCopy(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStore(t)
+
+	err := store.Put(ctx, "src/a", strings.NewReader("data"),
+		WithUploadContentType("application/json"),
+		WithUploadMetadata(map[string]string{"k": "v"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Copy(ctx, "src/a", "dst/b"); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := store.Stat(ctx, "src/a")
+	dst, err := store.Stat(ctx, "dst/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.ETag != src.ETag || dst.ContentType != "application/json" || dst.Metadata["k"] != "v" {
+		t.Errorf("Expected the copy to keep content and metadata, got %+v", dst)
+	}
+
+	if err := store.Delete(ctx, "src/a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(store.root, "src")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the empty directory to be removed, got %v", err)
+	}
+	// The key freed by the directory can now hold an object.
+	if err := store.Put(ctx, "src", strings.NewReader("x")); err != nil {
+		t.Errorf("Expected to reuse the key of the removed directory, got %v", err)
+	}
+}
+
+func TestLocalStoreWithoutSidecar(t *testing.T) {
+	store := newTestLocalStore(t)
+	if err := os.WriteFile(filepath.Join(store.root, "manual.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := store.Stat(context.Background(), "manual.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ETag != "5d41402abc4b2a76b9719d911017c592" || info.Metadata != nil {
+		t.Errorf("Unexpected info of a file without sidecar: %+v", info)
+	}
+}
@@ -0,0 +1,204 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// MoveStage tells how far a failed move got, and therefore which copies of
+// the object exist.
+type MoveStage int
+
+const (
+	// MoveCopyFailed means the copy failed; only the source exists.
+	MoveCopyFailed MoveStage = iota + 1
+	// MoveVerifyFailed means the destination was written but does not match
+	// the source; the source was kept.
+	MoveVerifyFailed
+	// MoveSourceChanged means the source was rewritten during the move; both
+	// the destination (old content) and the new source were kept.
+	MoveSourceChanged
+	// MoveDeleteFailed means the destination is complete but the source could
+	// not be deleted; both copies exist.
+	MoveDeleteFailed
+)
+
+func (s MoveStage) String() string {
+	switch s {
+	case MoveCopyFailed:
+		return "copy failed"
+	case MoveVerifyFailed:
+		return "verification failed"
+	case MoveSourceChanged:
+		return "source changed"
+	case MoveDeleteFailed:
+		return "delete failed"
+	default:
+		return fmt.Sprintf("MoveStage(%d)", int(s))
+	}
+}
+
+// MoveError reports a move that did not complete. Result is set for every
+// stage after the copy.
+type MoveError struct {
+	Stage     MoveStage
+	SrcBucket string
+	SrcKey    string
+	DstBucket string
+	DstKey    string
+	Result    *CopyResult
+	Err       error
+}
+
+func (e *MoveError) Error() string {
+	return fmt.Sprintf("unable to move object %q from bucket %q to %q in bucket %q (%s), %v",
+		e.SrcKey, e.SrcBucket, e.DstKey, e.DstBucket, e.Stage, e.Err)
+}
+
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
+
+func (a *AWSTools) MoveObject(srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	return a.MoveObjectWithContext(context.Background(), srcBucket, srcKey, dstBucket, dstKey, opts...)
+}
+
+// MoveObjectWithContext copies the object, checks that the destination has
+// the expected size and ETag, and only then deletes the source. Before the
+// delete the source is read again and must still have the ETag and version
+// that were copied; a versioned source is then deleted by that exact
+// version. Failures are returned as *MoveError.
+//
+// An unversioned source is deleted with an If-Match header as well, but that
+// is best-effort: the SDK does not model it and some servers ignore it, so a
+// rewrite landing between the final check and the delete can still be lost.
+func (a *AWSTools) MoveObjectWithContext(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	moveErr := &MoveError{SrcBucket: srcBucket, SrcKey: srcKey, DstBucket: dstBucket, DstKey: dstKey}
+
+	result, src, err := a.copyObject(ctx, srcBucket, srcKey, dstBucket, dstKey, opts...)
+	if err != nil {
+		moveErr.Stage, moveErr.Err = MoveCopyFailed, err
+		return nil, moveErr
+	}
+
+	return a.finishMove(ctx, a, src, result, moveErr, opts...)
+}
+
+func (a *AWSTools) MoveObjectTo(dst *AWSTools, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	return a.MoveObjectToWithContext(context.Background(), dst, srcBucket, srcKey, dstBucket, dstKey, opts...)
+}
+
+// MoveObjectToWithContext is the cross-instance variant of
+// MoveObjectWithContext, with the same verification and conditional delete.
+func (a *AWSTools) MoveObjectToWithContext(ctx context.Context, dst *AWSTools, srcBucket, srcKey, dstBucket, dstKey string, opts ...CopyOption) (*CopyResult, error) {
+	moveErr := &MoveError{SrcBucket: srcBucket, SrcKey: srcKey, DstBucket: dstBucket, DstKey: dstKey}
+
+	result, src, err := a.copyObjectTo(ctx, dst, srcBucket, srcKey, dstBucket, dstKey, opts...)
+	if err != nil {
+		moveErr.Stage, moveErr.Err = MoveCopyFailed, err
+		return nil, moveErr
+	}
+
+	return a.finishMove(ctx, dst, src, result, moveErr, opts...)
+}
+
+// finishMove verifies the destination through dst and deletes the source.
+// opts are the copy options, which carry the destination SSE-C key if any.
+func (a *AWSTools) finishMove(ctx context.Context, dst *AWSTools, src *ObjectInfo, result *CopyResult, moveErr *MoveError, opts ...CopyOption) (*CopyResult, error) {
+	moveErr.Result = result
+
+	if err := dst.verifyCopy(ctx, src, result, opts...); err != nil {
+		moveErr.Stage, moveErr.Err = MoveVerifyFailed, err
+		return result, moveErr
+	}
+
+	if err := a.deleteMovedSource(ctx, src, opts...); err != nil {
+		moveErr.Stage, moveErr.Err = MoveDeleteFailed, err
+		if errors.Is(err, ErrPreconditionFailed) {
+			moveErr.Stage = MoveSourceChanged
+		}
+		return result, moveErr
+	}
+
+	return result, nil
+}
+
+// verifyCopy checks that the destination described by result exists, has
+// the size of src and still carries the ETag returned by the copy.
+func (a *AWSTools) verifyCopy(ctx context.Context, src *ObjectInfo, result *CopyResult, opts ...CopyOption) error {
+	copyInput := newCopyParams(&s3.CopyObjectInput{}, opts...).input
+
+	dst, err := a.statObject(ctx, &s3.HeadObjectInput{
+		Bucket:               aws.String(result.Bucket),
+		Key:                  aws.String(result.Key),
+		SSECustomerAlgorithm: copyInput.SSECustomerAlgorithm,
+		SSECustomerKey:       copyInput.SSECustomerKey,
+		SSECustomerKeyMD5:    copyInput.SSECustomerKeyMD5,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Either side may be client-side encrypted with its own envelope.
+	if dstSize, srcSize := plaintextSize(dst), plaintextSize(src); dstSize != srcSize {
+		return fmt.Errorf("destination size %d does not match source size %d", dstSize, srcSize)
+	}
+
+	if result.ETag != "" && dst.ETag != result.ETag {
+		return fmt.Errorf("destination ETag %q does not match copied ETag %q", dst.ETag, result.ETag)
+	}
+
+	return nil
+}
+
+// deleteMovedSource deletes the copied source src. Unless a given version
+// was moved, the current source is read first and must still match src, or
+// the delete fails with ErrPreconditionFailed. When src has a version, that
+// version is deleted, so a rewrite that lands afterwards is never touched.
+// opts are the copy options, which carry the source SSE-C key if any.
+func (a *AWSTools) deleteMovedSource(ctx context.Context, src *ObjectInfo, opts ...CopyOption) error {
+	copyInput := newCopyParams(&s3.CopyObjectInput{}, opts...).input
+
+	if sourceVersion(copyInput.CopySource) == nil {
+		current, err := a.statObject(ctx, &s3.HeadObjectInput{
+			Bucket:               aws.String(src.Bucket),
+			Key:                  aws.String(src.Key),
+			SSECustomerAlgorithm: copyInput.CopySourceSSECustomerAlgorithm,
+			SSECustomerKey:       copyInput.CopySourceSSECustomerKey,
+			SSECustomerKeyMD5:    copyInput.CopySourceSSECustomerKeyMD5,
+		})
+		if err != nil {
+			return err
+		}
+		if current.ETag != src.ETag || (src.VersionID != "" && current.VersionID != src.VersionID) {
+			return fmt.Errorf("object %q in bucket %q changed after it was copied, %w", src.Key, src.Bucket, ErrPreconditionFailed)
+		}
+		// A source known only by its listed ETag is pinned to the version
+		// that was just read.
+		src = &ObjectInfo{Bucket: src.Bucket, Key: src.Key, ETag: src.ETag, VersionID: current.VersionID}
+	}
+
+	return a.deleteObjectIfMatch(ctx, src.Bucket, src.Key, src.ETag, src.VersionID)
+}
+
+// deleteObjectIfMatch deletes key, or the given version of it, sending
+// If-Match with etag. Servers that honour it fail a rewritten object with
+// ErrPreconditionFailed; others delete it regardless, so callers check the
+// ETag first.
+func (a *AWSTools) deleteObjectIfMatch(ctx context.Context, bucket, key, etag, versionID string) error {
+	_, err := a.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: optionalString(versionID),
+	}, s3.WithAPIOptions(smithyhttp.AddHeaderValue("If-Match", `"`+etag+`"`)))
+	if err != nil {
+		return fmt.Errorf("unable to delete object %q from bucket %q, %w", key, bucket, newOpError("DeleteObject", bucket, key, err))
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package awstools_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+// TestMoveObjectSourceRewrittenIgnoringIfMatch rewrites the source right
+// after it was copied, behind a proxy that drops If-Match like servers that
+// do not support it on DELETE. The move must keep the new source.
+func TestMoveObjectSourceRewrittenIgnoringIfMatch(t *testing.T) {
+	srv := awstoolstest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.CreateBucket("bucket")
+	srv.PutObject("bucket", "src", []byte("old"))
+
+	var deletes int
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletes++
+			r.Header.Del("If-Match")
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+		if r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "" {
+			srv.PutObject("bucket", "src", []byte("new"))
+		}
+	}))
+	t.Cleanup(proxy.Close)
+
+	tools, err := awstools.NewAWSTools(append(srv.Options(), awstools.WithEndpoint(proxy.URL))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tools.MoveObject("bucket", "src", "bucket", "dst")
+	var moveErr *awstools.MoveError
+	if !errors.As(err, &moveErr) || moveErr.Stage != awstools.MoveSourceChanged {
+		t.Fatalf("Expected MoveSourceChanged, got %v", err)
+	}
+	if !errors.Is(err, awstools.ErrPreconditionFailed) {
+		t.Errorf("Expected ErrPreconditionFailed, got %v", err)
+	}
+	if deletes != 0 {
+		t.Errorf("Expected no delete to be sent, got %d", deletes)
+	}
+	if data, ok := srv.Object("bucket", "src"); !ok || string(data) != "new" {
+		t.Errorf("Expected the rewritten source to be kept, got %q", data)
+	}
+	if data, _ := srv.Object("bucket", "dst"); string(data) != "old" {
+		t.Errorf("Expected the destination to hold the copied content, got %q", data)
+	}
+}
@@ -0,0 +1,95 @@
+package awstools
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestMoveErrorUnwrap(t *testing.T) {
+	cause := newOpError("DeleteObject", "src", "a.txt", newTestResponseError("DeleteObject", http.StatusPreconditionFailed, "PreconditionFailed"))
+
+	var err error = &MoveError{
+		Stage:     MoveSourceChanged,
+		SrcBucket: "src",
+		SrcKey:    "a.txt",
+		DstBucket: "dst",
+		DstKey:    "b.txt",
+		Result:    &CopyResult{Bucket: "dst", Key: "b.txt", ETag: "abc"},
+		Err:       cause,
+	}
+
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Error("Expected MoveError to unwrap to ErrPreconditionFailed")
+	}
+
+	var moveErr *MoveError
+	if !errors.As(err, &moveErr) || moveErr.Stage != MoveSourceChanged {
+		t.Fatalf("Expected *MoveError with stage %s, got %v", MoveSourceChanged, err)
+	}
+	if moveErr.Result == nil || moveErr.Result.ETag != "abc" {
+		t.Errorf("Expected copy result to be kept, got %+v", moveErr.Result)
+	}
+}
+
+func TestMoveStageString(t *testing.T) {
+	stages := map[MoveStage]string{
+		MoveCopyFailed:    "copy failed",
+		MoveVerifyFailed:  "verification failed",
+		MoveSourceChanged: "source changed",
+		MoveDeleteFailed:  "delete failed",
+		MoveStage(42):     "MoveStage(42)",
+	}
+
+	for stage, expected := range stages {
+		if got := stage.String(); got != expected {
+			t.Errorf("Expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestMoveObjectSourceVersion(t *testing.T) {
+	var mu sync.Mutex
+	var deletes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Content-Length", "4")
+			w.Header().Set("ETag", `"abc"`)
+			if v := r.URL.Query().Get("versionId"); v != "" {
+				w.Header().Set("x-amz-version-id", v)
+			}
+		case http.MethodPut:
+			w.Write([]byte(`<CopyObjectResult><ETag>"abc"</ETag></CopyObjectResult>`))
+		case http.MethodDelete:
+			mu.Lock()
+			deletes = append(deletes, r.URL.Query().Get("versionId"))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	tools, err := NewAWSTools(
+		WithEndpoint(srv.URL),
+		WithAccessKeyID("test-key"),
+		WithSecretKey("test-secret"),
+		WithRegion("us-east-1"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create AWSTools: %v", err)
+	}
+
+	if _, err := tools.MoveObject("bucket", "src", "bucket", "dst", WithCopySourceVersion("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tools.MoveObject("bucket", "src", "bucket", "dst"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deletes) != 2 || deletes[0] != "v1" || deletes[1] != "" {
+		t.Errorf("Expected the copied version and then the current one to be deleted, got %q", deletes)
+	}
+}
@@ -0,0 +1,286 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// defaultCleanupAge is the minimum age of the uploads aborted by
+// CleanupMultipartUploads, so that a cron job does not abort uploads that
+// are still running.
+const defaultCleanupAge = 24 * time.Hour
+
+// errUploadGone marks an upload completed or aborted by someone else during
+// a cleanup.
+var errUploadGone = errors.New("multipart upload no longer exists")
+
+// MultipartUpload is a multipart upload that was started and neither
+// completed nor aborted. Its parts are stored, and billed, until then.
+type MultipartUpload struct {
+	Bucket       string
+	Key          string
+	UploadID     string
+	Initiated    time.Time
+	StorageClass types.StorageClass
+	// Size is the total size of the uploaded parts. It is only filled by
+	// CleanupMultipartUploads.
+	Size int64
+}
+
+// MultipartCleanupResult reports the uploads aborted by a cleanup. In
+// dry-run mode Aborted lists the uploads that would have been aborted.
+type MultipartCleanupResult struct {
+	Aborted        []MultipartUpload
+	BytesReclaimed int64
+	DryRun         bool
+}
+
+// MultipartUploadsOption customizes the listing, abort and cleanup of
+// incomplete multipart uploads.
+type MultipartUploadsOption func(*multipartUploadsParams)
+
+type multipartUploadsParams struct {
+	prefix      string
+	olderThan   time.Duration
+	dryRun      bool
+	concurrency int
+}
+
+func (a *AWSTools) newMultipartUploadsParams(olderThan time.Duration, opts ...MultipartUploadsOption) *multipartUploadsParams {
+	p := &multipartUploadsParams{
+		olderThan:   olderThan,
+		concurrency: a.queueWorkers,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// WithUploadsPrefix only considers uploads of keys starting with prefix.
+func WithUploadsPrefix(prefix string) MultipartUploadsOption {
+	return func(p *multipartUploadsParams) {
+		p.prefix = prefix
+	}
+}
+
+// WithUploadsOlderThan only considers uploads initiated more than age ago.
+// A zero age considers every upload.
+func WithUploadsOlderThan(age time.Duration) MultipartUploadsOption {
+	return func(p *multipartUploadsParams) {
+		p.olderThan = age
+	}
+}
+
+// WithUploadsDryRun reports the uploads a cleanup would abort without
+// aborting them.
+func WithUploadsDryRun() MultipartUploadsOption {
+	return func(p *multipartUploadsParams) {
+		p.dryRun = true
+	}
+}
+
+// WithUploadsConcurrency sets how many uploads are sized or aborted in
+// parallel. It defaults to the number of stream workers configured on
+// AWSTools.
+func WithUploadsConcurrency(n int) MultipartUploadsOption {
+	return func(p *multipartUploadsParams) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+func (a *AWSTools) ListMultipartUploads(bucket string, opts ...MultipartUploadsOption) iter.Seq2[MultipartUpload, error] {
+	return a.ListMultipartUploadsWithContext(context.Background(), bucket, opts...)
+}
+
+// ListMultipartUploadsWithContext iterates over the incomplete multipart
+// uploads of bucket, ordered by key and initiation time. Pages are fetched
+// as the iteration advances; a listing failure is yielded once as the error
+// and ends the iteration.
+func (a *AWSTools) ListMultipartUploadsWithContext(ctx context.Context, bucket string, opts ...MultipartUploadsOption) iter.Seq2[MultipartUpload, error] {
+	params := a.newMultipartUploadsParams(0, opts...)
+	return a.listMultipartUploads(ctx, bucket, params)
+}
+
+func (a *AWSTools) listMultipartUploads(ctx context.Context, bucket string, params *multipartUploadsParams) iter.Seq2[MultipartUpload, error] {
+	return func(yield func(MultipartUpload, error) bool) {
+		cutoff := time.Now().Add(-params.olderThan)
+
+		paginator := s3.NewListMultipartUploadsPaginator(a.s3Client, &s3.ListMultipartUploadsInput{
+			Bucket: aws.String(bucket),
+			Prefix: optionalString(params.prefix),
+		})
+
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				yield(MultipartUpload{}, fmt.Errorf("unable to list multipart uploads in bucket %q, %w",
+					bucket, newOpError("ListMultipartUploads", bucket, params.prefix, err)))
+				return
+			}
+
+			for _, upload := range page.Uploads {
+				initiated := aws.ToTime(upload.Initiated)
+				if params.olderThan > 0 && !initiated.Before(cutoff) {
+					continue
+				}
+				if !yield(MultipartUpload{
+					Bucket:       bucket,
+					Key:          aws.ToString(upload.Key),
+					UploadID:     aws.ToString(upload.UploadId),
+					Initiated:    initiated,
+					StorageClass: upload.StorageClass,
+				}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *AWSTools) AbortMultipartUploads(uploads []MultipartUpload, opts ...MultipartUploadsOption) error {
+	return a.AbortMultipartUploadsWithContext(context.Background(), uploads, opts...)
+}
+
+// AbortMultipartUploadsWithContext aborts uploads, releasing their parts.
+// Uploads that no longer exist are not an error. Every failure is reported
+// in the returned error.
+func (a *AWSTools) AbortMultipartUploadsWithContext(ctx context.Context, uploads []MultipartUpload, opts ...MultipartUploadsOption) error {
+	params := a.newMultipartUploadsParams(0, opts...)
+	return errors.Join(a.forEachUpload(ctx, uploads, params.concurrency, a.abortUpload)...)
+}
+
+func (a *AWSTools) CleanupMultipartUploads(bucket string, opts ...MultipartUploadsOption) (*MultipartCleanupResult, error) {
+	return a.CleanupMultipartUploadsWithContext(context.Background(), bucket, opts...)
+}
+
+// CleanupMultipartUploadsWithContext aborts the incomplete multipart uploads
+// of bucket initiated more than 24 hours ago, or WithUploadsOlderThan, and
+// reports the bytes their parts occupied. Uploads that could not be sized or
+// aborted are left out of the result and reported in the error.
+func (a *AWSTools) CleanupMultipartUploadsWithContext(ctx context.Context, bucket string, opts ...MultipartUploadsOption) (*MultipartCleanupResult, error) {
+	params := a.newMultipartUploadsParams(defaultCleanupAge, opts...)
+
+	var uploads []MultipartUpload
+	for upload, err := range a.listMultipartUploads(ctx, bucket, params) {
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+
+	errs := a.forEachUpload(ctx, uploads, params.concurrency, func(ctx context.Context, upload *MultipartUpload) error {
+		size, err := a.uploadedSize(ctx, upload)
+		if errors.Is(err, ErrNotFound) {
+			return errUploadGone
+		}
+		if err != nil {
+			return err
+		}
+		upload.Size = size
+		if params.dryRun {
+			return nil
+		}
+		return a.abortUpload(ctx, upload)
+	})
+
+	result := &MultipartCleanupResult{DryRun: params.dryRun}
+	for i, upload := range uploads {
+		if errors.Is(errs[i], errUploadGone) {
+			errs[i] = nil
+			continue
+		}
+		if errs[i] != nil {
+			continue
+		}
+		result.Aborted = append(result.Aborted, upload)
+		result.BytesReclaimed += upload.Size
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// forEachUpload calls fn for every upload with up to concurrency calls in
+// parallel, and returns the error of each call at the index of its upload.
+func (a *AWSTools) forEachUpload(ctx context.Context, uploads []MultipartUpload, concurrency int,
+	fn func(context.Context, *MultipartUpload) error) []error {
+	errs := make([]error, len(uploads))
+	jobs := make(chan int)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				errs[idx] = fn(ctx, &uploads[idx])
+			}
+		}()
+	}
+
+dispatch:
+	for idx := range uploads {
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			for ; idx < len(uploads); idx++ {
+				errs[idx] = ctx.Err()
+			}
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+// abortUpload aborts one upload, ignoring uploads that are already gone.
+func (a *AWSTools) abortUpload(ctx context.Context, upload *MultipartUpload) error {
+	_, err := a.s3Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(upload.Bucket),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.UploadID),
+	})
+	if err == nil {
+		return nil
+	}
+	if opErr := newOpError("AbortMultipartUpload", upload.Bucket, upload.Key, err); !errors.Is(opErr, ErrNotFound) {
+		return fmt.Errorf("unable to abort upload %q of %q in bucket %q, %w", upload.UploadID, upload.Key, upload.Bucket, opErr)
+	}
+	return nil
+}
+
+// uploadedSize sums the sizes of the parts of an upload.
+func (a *AWSTools) uploadedSize(ctx context.Context, upload *MultipartUpload) (int64, error) {
+	paginator := s3.NewListPartsPaginator(a.s3Client, &s3.ListPartsInput{
+		Bucket:   aws.String(upload.Bucket),
+		Key:      aws.String(upload.Key),
+		UploadId: aws.String(upload.UploadID),
+	})
+
+	var size int64
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("unable to list parts of upload %q, %w",
+				upload.UploadID, newOpError("ListParts", upload.Bucket, upload.Key, err))
+		}
+		for _, part := range page.Parts {
+			size += aws.ToInt64(part.Size)
+		}
+	}
+	return size, nil
+}
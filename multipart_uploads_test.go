@@ -0,0 +1,63 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMultipartUploadsParams(t *testing.T) {
+	a := &AWSTools{queueWorkers: 4}
+
+	p := a.newMultipartUploadsParams(defaultCleanupAge)
+	if p.olderThan != 24*time.Hour || p.concurrency != 4 || p.dryRun {
+		t.Errorf("Unexpected cleanup defaults: %+v", p)
+	}
+
+	p = a.newMultipartUploadsParams(defaultCleanupAge,
+		WithUploadsPrefix("backups/"),
+		WithUploadsOlderThan(0),
+		WithUploadsDryRun(),
+		WithUploadsConcurrency(0),
+	)
+	if p.prefix != "backups/" || p.olderThan != 0 || !p.dryRun || p.concurrency != 4 {
+		t.Errorf("Unexpected params: %+v", p)
+	}
+}
+
+func TestForEachUpload(t *testing.T) {
+	a := &AWSTools{}
+	uploads := []MultipartUpload{{Key: "a"}, {Key: "b"}, {Key: "c"}}
+	failure := errors.New("boom")
+
+	var calls atomic.Int32
+	errs := a.forEachUpload(context.Background(), uploads, 2, func(_ context.Context, upload *MultipartUpload) error {
+		calls.Add(1)
+		upload.Size = int64(len(upload.Key))
+		if upload.Key == "b" {
+			return failure
+		}
+		return nil
+	})
+
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls.Load())
+	}
+	if errs[0] != nil || errs[1] != failure || errs[2] != nil {
+		t.Errorf("Expected errors at the index of their upload, got %v", errs)
+	}
+	if uploads[2].Size != 1 {
+		t.Error("Expected fn to update the upload in place")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs = a.forEachUpload(ctx, uploads, 1, func(context.Context, *MultipartUpload) error { return nil })
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// defaultReadAhead is the minimum number of bytes an ObjectReader fetches
+// per request.
+const defaultReadAhead = 1024 * 1024
+
+func (a *AWSTools) GetObjectRange(bucket, key string, offset, length int64, opts ...DownloadOption) ([]byte, error) {
+	return a.GetObjectRangeWithContext(context.Background(), bucket, key, offset, length, opts...)
+}
+
+// GetObjectRangeWithContext reads length bytes of the object starting at
+// offset. A negative length reads up to the end of the object, and a
+// negative offset reads the last -offset bytes, e.g. a file footer, in which
+// case length is ignored. Fewer bytes are returned when the range extends
+// past the end of the object.
+func (a *AWSTools) GetObjectRangeWithContext(ctx context.Context, bucket, key string, offset, length int64, opts ...DownloadOption) ([]byte, error) {
+	params := newDownloadParams(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, opts...)
+	if params.err != nil {
+		return nil, params.err
+	}
+	input := params.input
+
+	switch {
+	case offset < 0:
+		input.Range = aws.String("bytes=" + strconv.FormatInt(offset, 10))
+	case length < 0:
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	case length == 0:
+		return []byte{}, nil
+	default:
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+
+	return a.readRange(ctx, input)
+}
+
+// readRange performs a ranged GetObject and reads the whole body.
+func (a *AWSTools) readRange(ctx context.Context, input *s3.GetObjectInput) ([]byte, error) {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	out, err := a.s3Client.GetObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read range %s of object %q in bucket %q, %w",
+			aws.ToString(input.Range), key, bucket, newOpError("GetObject", bucket, key, err))
+	}
+	defer out.Body.Close()
+
+	if isEncrypted(out.Metadata) {
+		return nil, errors.New("client-side encrypted objects cannot be read by range")
+	}
+	if err := a.checkEnvelope(out.Metadata); err != nil {
+		return nil, fmt.Errorf("unable to read object %q in bucket %q, %w", key, bucket, err)
+	}
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read range %s of object %q in bucket %q, %w",
+			aws.ToString(input.Range), key, bucket, err)
+	}
+	return data, nil
+}
+
+// ObjectReader reads an S3 object with ranged GETs. It implements
+// io.ReadSeekCloser and io.ReaderAt, so it can be handed to archive/zip or
+// Parquet readers without downloading the whole object. Every request is
+// pinned to the ETag seen when the object was opened: if the object is
+// rewritten, reads fail with ErrPreconditionFailed instead of mixing
+// revisions.
+//
+// Reads fetch at least the read-ahead size and keep the last range fetched,
+// so small sequential reads do not each cost a request. ReadAt may be called
+// concurrently; Read and Seek share one offset and must not.
+type ObjectReader struct {
+	a         *AWSTools
+	ctx       context.Context
+	input     s3.GetObjectInput
+	info      *ObjectInfo
+	readAhead int64
+
+	offset int64 // position of Read and Seek
+
+	mu       sync.Mutex
+	buf      []byte // last range fetched
+	bufStart int64
+	closed   bool
+}
+
+func (a *AWSTools) OpenObject(bucket, key string, opts ...DownloadOption) (*ObjectReader, error) {
+	return a.OpenObjectWithContext(context.Background(), bucket, key, opts...)
+}
+
+// OpenObjectWithContext opens the object for random access. ctx is used by
+// every read made through the returned reader.
+func (a *AWSTools) OpenObjectWithContext(ctx context.Context, bucket, key string, opts ...DownloadOption) (*ObjectReader, error) {
+	params := newDownloadParams(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, opts...)
+	if params.err != nil {
+		return nil, params.err
+	}
+	input := params.input
+
+	info, err := a.statObject(ctx, &s3.HeadObjectInput{
+		Bucket:               input.Bucket,
+		Key:                  input.Key,
+		VersionId:            input.VersionId,
+		SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+		SSECustomerKey:       input.SSECustomerKey,
+		SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if isEncrypted(info.Metadata) {
+		return nil, errors.New("client-side encrypted objects cannot be read by range")
+	}
+	if err := a.checkEnvelope(info.Metadata); err != nil {
+		return nil, fmt.Errorf("unable to open object %q in bucket %q, %w", aws.ToString(input.Key), aws.ToString(input.Bucket), err)
+	}
+
+	return a.newObjectReader(ctx, input, info, params.readAhead), nil
+}
+
+// newObjectReader returns a reader of the object described by info, pinned to
+// its ETag and version.
+func (a *AWSTools) newObjectReader(ctx context.Context, input *s3.GetObjectInput, info *ObjectInfo, readAhead int64) *ObjectReader {
+	pinned := *input
+	pinned.IfMatch = aws.String(`"` + info.ETag + `"`)
+	pinned.VersionId = optionalString(info.VersionID)
+	pinned.ChecksumMode = ""
+
+	if readAhead <= 0 {
+		readAhead = defaultReadAhead
+	}
+
+	return &ObjectReader{
+		a:         a,
+		ctx:       ctx,
+		input:     pinned,
+		info:      info,
+		readAhead: readAhead,
+	}
+}
+
+// Size returns the size of the object.
+func (r *ObjectReader) Size() int64 {
+	return r.info.Size
+}
+
+// Info returns the object metadata read when it was opened.
+func (r *ObjectReader) Info() *ObjectInfo {
+	return r.info
+}
+
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.info.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// ReadAt reads len(p) bytes at off, fetching at least the read-ahead size
+// when they are not in the last range fetched.
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return 0, fs.ErrClosed
+	}
+	buf, bufStart := r.buf, r.bufStart
+	r.mu.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= r.info.Size {
+		return 0, io.EOF
+	}
+	want := int64(len(p))
+	if rest := r.info.Size - off; want > rest {
+		want = rest
+	}
+
+	if off < bufStart || off+want > bufStart+int64(len(buf)) {
+		fetch := max(want, r.readAhead)
+		fetch = min(fetch, r.info.Size-off)
+
+		input := r.input
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", off, off+fetch-1))
+		data, err := r.a.readRange(r.ctx, &input)
+		if err != nil {
+			return 0, err
+		}
+		if int64(len(data)) < want {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		buf, bufStart = data, off
+		r.mu.Lock()
+		r.buf, r.bufStart = buf, bufStart
+		r.mu.Unlock()
+	}
+
+	n := copy(p, buf[off-bufStart:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close releases the buffered range. Later reads fail.
+func (r *ObjectReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.buf = nil
+	return nil
+}
@@ -0,0 +1,70 @@
+package awstools
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+// bufferedReader returns a reader whose whole object is already buffered,
+// so no request is made.
+func bufferedReader(data string) *ObjectReader {
+	return &ObjectReader{
+		info:      &ObjectInfo{Size: int64(len(data))},
+		readAhead: defaultReadAhead,
+		buf:       []byte(data),
+	}
+}
+
+func TestObjectReaderReadAt(t *testing.T) {
+	r := bufferedReader("0123456789")
+
+	p := make([]byte, 4)
+	if n, err := r.ReadAt(p, 3); n != 4 || err != nil || string(p) != "3456" {
+		t.Errorf("Unexpected ReadAt: %d %v %q", n, err, p)
+	}
+	if n, err := r.ReadAt(p, 8); n != 2 || err != io.EOF || string(p[:n]) != "89" {
+		t.Errorf("Expected a short read with EOF at the end, got %d %v", n, err)
+	}
+	if _, err := r.ReadAt(p, 10); err != io.EOF {
+		t.Errorf("Expected EOF past the end, got %v", err)
+	}
+
+	// Empty reads make no request, even outside the buffered range.
+	unbuffered := &ObjectReader{info: &ObjectInfo{Size: 10}, readAhead: defaultReadAhead}
+	for _, off := range []int64{5, 10} {
+		if n, err := unbuffered.ReadAt(nil, off); n != 0 || err != nil {
+			t.Errorf("Expected an empty read at %d to return 0, nil, got %d %v", off, n, err)
+		}
+	}
+}
+
+func TestObjectReaderSeek(t *testing.T) {
+	r := bufferedReader("0123456789")
+
+	if pos, err := r.Seek(-3, io.SeekEnd); pos != 7 || err != nil {
+		t.Fatalf("Unexpected seek: %d %v", pos, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != "789" {
+		t.Errorf("Unexpected tail: %q %v", data, err)
+	}
+
+	r.Seek(2, io.SeekStart)
+	if pos, _ := r.Seek(3, io.SeekCurrent); pos != 5 {
+		t.Errorf("Expected position 5, got %d", pos)
+	}
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Expected an error for a negative position")
+	}
+}
+
+func TestObjectReaderClose(t *testing.T) {
+	r := bufferedReader("data")
+	r.Close()
+
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("Expected fs.ErrClosed after Close, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectInfo holds the metadata S3 returns for an object.
+type ObjectInfo struct {
+	Bucket               string
+	Key                  string
+	Size                 int64
+	ETag                 string
+	ContentType          string
+	ContentEncoding      string
+	ContentDisposition   string
+	ContentLanguage      string
+	CacheControl         string
+	Metadata             map[string]string
+	LastModified         time.Time
+	StorageClass         types.StorageClass
+	VersionID            string
+	ServerSideEncryption types.ServerSideEncryption
+	SSEKMSKeyID          string
+	SSECustomerAlgorithm string
+	BucketKeyEnabled     bool
+}
+
+func (a *AWSTools) StatObject(bucket, key string) (*ObjectInfo, error) {
+	return a.StatObjectWithContext(context.Background(), bucket, key)
+}
+
+func (a *AWSTools) StatObjectWithContext(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	return a.statObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+}
+
+func (a *AWSTools) statObject(ctx context.Context, input *s3.HeadObjectInput) (*ObjectInfo, error) {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	result, err := a.s3Client.HeadObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat object %q in bucket %q, %w", key, bucket, newOpError("HeadObject", bucket, key, err))
+	}
+
+	return newObjectInfo(bucket, key, result), nil
+}
+
+func (a *AWSTools) ObjectExists(bucket, key string) (bool, error) {
+	return a.ObjectExistsWithContext(context.Background(), bucket, key)
+}
+
+// ObjectExistsWithContext reports whether key exists in bucket. A missing
+// object returns false with a nil error; a missing bucket returns an error
+// matching ErrBucketNotFound, and any other failure is returned as well.
+func (a *AWSTools) ObjectExistsWithContext(ctx context.Context, bucket, key string) (bool, error) {
+	_, err := a.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err == nil {
+		return true, nil
+	}
+
+	opErr := newOpError("HeadObject", bucket, key, err)
+	if errors.Is(opErr, ErrNotFound) {
+		// HEAD responses carry no error code, so a missing bucket looks
+		// like a missing key until the bucket itself is checked.
+		_, err := a.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: aws.String(bucket),
+		})
+		if err == nil {
+			return false, nil
+		}
+		opErr = newOpError("HeadBucket", bucket, "", err)
+	}
+
+	return false, fmt.Errorf("unable to check object %q in bucket %q, %w", key, bucket, opErr)
+}
+
+func newObjectInfo(bucket, key string, out *s3.HeadObjectOutput) *ObjectInfo {
+	return &ObjectInfo{
+		Bucket:               bucket,
+		Key:                  key,
+		Size:                 aws.ToInt64(out.ContentLength),
+		ETag:                 trimETag(aws.ToString(out.ETag)),
+		ContentType:          aws.ToString(out.ContentType),
+		ContentEncoding:      aws.ToString(out.ContentEncoding),
+		ContentDisposition:   aws.ToString(out.ContentDisposition),
+		ContentLanguage:      aws.ToString(out.ContentLanguage),
+		CacheControl:         aws.ToString(out.CacheControl),
+		Metadata:             out.Metadata,
+		LastModified:         aws.ToTime(out.LastModified),
+		StorageClass:         out.StorageClass,
+		VersionID:            aws.ToString(out.VersionId),
+		ServerSideEncryption: out.ServerSideEncryption,
+		SSEKMSKeyID:          aws.ToString(out.SSEKMSKeyId),
+		SSECustomerAlgorithm: aws.ToString(out.SSECustomerAlgorithm),
+		BucketKeyEnabled:     aws.ToBool(out.BucketKeyEnabled),
+	}
+}
+
+// trimETag removes the double quotes S3 puts around ETag values.
+func trimETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
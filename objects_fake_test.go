@@ -0,0 +1,29 @@
+package awstools_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+func TestObjectExistsMissingBucket(t *testing.T) {
+	tools, srv := awstoolstest.New(t)
+	srv.PutObject("bucket", "key", []byte("data"))
+
+	if exists, err := tools.ObjectExists("bucket", "key"); err != nil || !exists {
+		t.Errorf("Expected the object to exist: %v %v", exists, err)
+	}
+	if exists, err := tools.ObjectExists("bucket", "missing"); err != nil || exists {
+		t.Errorf("Expected a missing object without error: %v %v", exists, err)
+	}
+
+	exists, err := tools.ObjectExists("missing", "key")
+	if !errors.Is(err, awstools.ErrBucketNotFound) {
+		t.Errorf("Expected ErrBucketNotFound for a missing bucket, got %v", err)
+	}
+	if exists {
+		t.Error("Expected a missing bucket not to report the object as existing")
+	}
+}
@@ -0,0 +1,55 @@
+package awstools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestNewObjectInfo(t *testing.T) {
+	modified := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	info := newObjectInfo("bucket", "dir/file.txt", &s3.HeadObjectOutput{
+		ContentLength:        aws.Int64(42),
+		ETag:                 aws.String(`"abc123"`),
+		ContentType:          aws.String("text/plain"),
+		Metadata:             map[string]string{"owner": "team"},
+		LastModified:         aws.Time(modified),
+		StorageClass:         types.StorageClassStandardIa,
+		VersionId:            aws.String("v1"),
+		ServerSideEncryption: types.ServerSideEncryptionAwsKms,
+		SSEKMSKeyId:          aws.String("key-id"),
+		BucketKeyEnabled:     aws.Bool(true),
+	})
+
+	if info.Bucket != "bucket" || info.Key != "dir/file.txt" {
+		t.Errorf("Unexpected bucket/key: %s/%s", info.Bucket, info.Key)
+	}
+	if info.Size != 42 {
+		t.Errorf("Expected size 42, got %d", info.Size)
+	}
+	if info.ETag != "abc123" {
+		t.Errorf("Expected unquoted ETag abc123, got %s", info.ETag)
+	}
+	if info.ContentType != "text/plain" {
+		t.Errorf("Expected content type text/plain, got %s", info.ContentType)
+	}
+	if info.Metadata["owner"] != "team" {
+		t.Errorf("Expected metadata owner=team, got %v", info.Metadata)
+	}
+	if !info.LastModified.Equal(modified) {
+		t.Errorf("Expected last modified %v, got %v", modified, info.LastModified)
+	}
+	if info.StorageClass != types.StorageClassStandardIa {
+		t.Errorf("Expected storage class STANDARD_IA, got %s", info.StorageClass)
+	}
+	if info.VersionID != "v1" {
+		t.Errorf("Expected version v1, got %s", info.VersionID)
+	}
+	if info.ServerSideEncryption != types.ServerSideEncryptionAwsKms || info.SSEKMSKeyID != "key-id" || !info.BucketKeyEnabled {
+		t.Errorf("Unexpected SSE info: %+v", info)
+	}
+}
@@ -1,5 +1,11 @@
 package awstools
 
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+)
+
 type Options func(*AWSToolsParams) error
 
 type AWSToolsParams struct {
@@ -11,6 +17,20 @@ type AWSToolsParams struct {
 	workersRLS   int // amount of worker read line Stream
 	endpoint     string
 	disableSSL   bool
+
+	multipartCopyThreshold int64 // source size above which copies use UploadPartCopy
+	multipartCopyPartSize  int64 // part size used by multipart copies
+
+	keyWrapper       KeyWrapper // enables client-side encryption when set
+	unencryptedReads bool       // lets client-side encryption read objects without an envelope
+
+	uploadPartSize         int64 // part size of multipart uploads
+	uploadConcurrency      int   // parts uploaded in parallel
+	leavePartsOnError      bool  // keep uploaded parts when a multipart upload fails
+	uploadBufferProvider   manager.ReadSeekerWriteToProvider
+	downloadPartSize       int64 // size of the ranges fetched by downloads
+	downloadConcurrency    int   // ranges downloaded in parallel
+	downloadBufferProvider manager.WriterReadFromProvider
 }
 
 func newAWSToolsParams(opts ...Options) (*AWSToolsParams, error) {
@@ -27,6 +47,24 @@ func newAWSToolsParams(opts ...Options) (*AWSToolsParams, error) {
 	if awsToolsParams.workersRLS == 0 {
 		awsToolsParams.workersRLS = 4
 	}
+	if awsToolsParams.multipartCopyThreshold == 0 {
+		awsToolsParams.multipartCopyThreshold = maxSingleCopySize
+	}
+	if awsToolsParams.multipartCopyPartSize == 0 {
+		awsToolsParams.multipartCopyPartSize = 256 * 1024 * 1024
+	}
+	if awsToolsParams.uploadPartSize == 0 {
+		awsToolsParams.uploadPartSize = manager.DefaultUploadPartSize
+	}
+	if awsToolsParams.uploadConcurrency == 0 {
+		awsToolsParams.uploadConcurrency = manager.DefaultUploadConcurrency
+	}
+	if awsToolsParams.downloadPartSize == 0 {
+		awsToolsParams.downloadPartSize = manager.DefaultDownloadPartSize
+	}
+	if awsToolsParams.downloadConcurrency == 0 {
+		awsToolsParams.downloadConcurrency = manager.DefaultDownloadConcurrency
+	}
 	return awsToolsParams, nil
 }
 
@@ -86,6 +124,133 @@ func WithDisableSSL(disable bool) Options {
 	}
 }
 
+// WithMultipartCopyThreshold sets the source size above which copies are done
+// with UploadPartCopy instead of a single CopyObject. It cannot exceed 5 GiB,
+// the CopyObject limit.
+func WithMultipartCopyThreshold(threshold int64) Options {
+	return func(p *AWSToolsParams) error {
+		if threshold <= 0 || threshold > maxSingleCopySize {
+			return fmt.Errorf("multipart copy threshold must be between 1 and %d bytes", int64(maxSingleCopySize))
+		}
+		p.multipartCopyThreshold = threshold
+		return nil
+	}
+}
+
+// WithMultipartCopyPartSize sets the part size used by multipart copies. It
+// is raised automatically when the object would need more than 10000 parts.
+func WithMultipartCopyPartSize(partSize int64) Options {
+	return func(p *AWSToolsParams) error {
+		if partSize < minPartSize || partSize > maxSingleCopySize {
+			return fmt.Errorf("multipart copy part size must be between %d and %d bytes", int64(minPartSize), int64(maxSingleCopySize))
+		}
+		p.multipartCopyPartSize = partSize
+		return nil
+	}
+}
+
+// WithClientEncryption encrypts uploads in the process with AES-GCM before
+// they are sent, using a random data key per object wrapped by wrapper.
+// Downloads and stream reads of encrypted objects are decrypted transparently;
+// reads of objects without an envelope fail unless WithUnencryptedReads is
+// given.
+func WithClientEncryption(wrapper KeyWrapper) Options {
+	return func(p *AWSToolsParams) error {
+		if wrapper == nil {
+			return fmt.Errorf("client encryption requires a key wrapper")
+		}
+		p.keyWrapper = wrapper
+		return nil
+	}
+}
+
+// WithUnencryptedReads lets an instance configured with WithClientEncryption
+// read objects without an envelope as they are. Without it such reads fail
+// with ErrNotEncrypted, since anyone able to write to the bucket could
+// otherwise substitute unauthenticated content.
+func WithUnencryptedReads() Options {
+	return func(p *AWSToolsParams) error {
+		p.unencryptedReads = true
+		return nil
+	}
+}
+
+// WithMultipartUploadPartSize sets the part size of multipart uploads. Files
+// up to one part are sent with a single PutObject. The minimum is 5 MiB.
+func WithMultipartUploadPartSize(partSize int64) Options {
+	return func(p *AWSToolsParams) error {
+		if partSize < manager.MinUploadPartSize {
+			return fmt.Errorf("multipart upload part size must be at least %d bytes", int64(manager.MinUploadPartSize))
+		}
+		p.uploadPartSize = partSize
+		return nil
+	}
+}
+
+// WithMultipartUploadConcurrency sets how many parts of one upload are sent
+// in parallel.
+func WithMultipartUploadConcurrency(concurrency int) Options {
+	return func(p *AWSToolsParams) error {
+		if concurrency <= 0 {
+			return fmt.Errorf("multipart upload concurrency must be positive")
+		}
+		p.uploadConcurrency = concurrency
+		return nil
+	}
+}
+
+// WithMultipartUploadLeavePartsOnError keeps the parts of a failed multipart
+// upload instead of aborting it, so they can be inspected or reused. The
+// parts are billed until the upload is completed or aborted.
+func WithMultipartUploadLeavePartsOnError(leave bool) Options {
+	return func(p *AWSToolsParams) error {
+		p.leavePartsOnError = leave
+		return nil
+	}
+}
+
+// WithMultipartUploadBufferProvider sets the provider of the buffers parts
+// are read into before being sent, e.g. manager.NewBufferedReadSeekerWriteToPool.
+func WithMultipartUploadBufferProvider(provider manager.ReadSeekerWriteToProvider) Options {
+	return func(p *AWSToolsParams) error {
+		p.uploadBufferProvider = provider
+		return nil
+	}
+}
+
+// WithMultipartDownloadPartSize sets the size of the byte ranges downloads
+// fetch in parallel.
+func WithMultipartDownloadPartSize(partSize int64) Options {
+	return func(p *AWSToolsParams) error {
+		if partSize <= 0 {
+			return fmt.Errorf("multipart download part size must be positive")
+		}
+		p.downloadPartSize = partSize
+		return nil
+	}
+}
+
+// WithMultipartDownloadConcurrency sets how many ranges of one download are
+// fetched in parallel.
+func WithMultipartDownloadConcurrency(concurrency int) Options {
+	return func(p *AWSToolsParams) error {
+		if concurrency <= 0 {
+			return fmt.Errorf("multipart download concurrency must be positive")
+		}
+		p.downloadConcurrency = concurrency
+		return nil
+	}
+}
+
+// WithMultipartDownloadBufferProvider sets the provider of the buffers ranges
+// are copied through, e.g. manager.NewPooledBufferedWriterReadFromProvider.
+func WithMultipartDownloadBufferProvider(provider manager.WriterReadFromProvider) Options {
+	return func(p *AWSToolsParams) error {
+		p.downloadBufferProvider = provider
+		return nil
+	}
+}
+
 // getters -----
 
 func (p *AWSToolsParams) Region() string {
@@ -120,6 +285,50 @@ func (p *AWSToolsParams) DisableSSL() bool {
 	return p.disableSSL
 }
 
+func (p *AWSToolsParams) MultipartCopyThreshold() int64 {
+	return p.multipartCopyThreshold
+}
+
+func (p *AWSToolsParams) MultipartCopyPartSize() int64 {
+	return p.multipartCopyPartSize
+}
+
+func (p *AWSToolsParams) ClientEncryption() KeyWrapper {
+	return p.keyWrapper
+}
+
+func (p *AWSToolsParams) UnencryptedReads() bool {
+	return p.unencryptedReads
+}
+
+func (p *AWSToolsParams) MultipartUploadPartSize() int64 {
+	return p.uploadPartSize
+}
+
+func (p *AWSToolsParams) MultipartUploadConcurrency() int {
+	return p.uploadConcurrency
+}
+
+func (p *AWSToolsParams) MultipartUploadLeavePartsOnError() bool {
+	return p.leavePartsOnError
+}
+
+func (p *AWSToolsParams) MultipartUploadBufferProvider() manager.ReadSeekerWriteToProvider {
+	return p.uploadBufferProvider
+}
+
+func (p *AWSToolsParams) MultipartDownloadPartSize() int64 {
+	return p.downloadPartSize
+}
+
+func (p *AWSToolsParams) MultipartDownloadConcurrency() int {
+	return p.downloadConcurrency
+}
+
+func (p *AWSToolsParams) MultipartDownloadBufferProvider() manager.WriterReadFromProvider {
+	return p.downloadBufferProvider
+}
+
 // setters -----
 
 func (p *AWSToolsParams) SetRegion(region string) {
@@ -149,3 +358,47 @@ func (p *AWSToolsParams) SetAmountWorkersRLS(workersRLS int) {
 func (p *AWSToolsParams) SetEndpoint(endpoint string) {
 	p.endpoint = endpoint
 }
+
+func (p *AWSToolsParams) SetMultipartCopyThreshold(threshold int64) {
+	p.multipartCopyThreshold = threshold
+}
+
+func (p *AWSToolsParams) SetMultipartCopyPartSize(partSize int64) {
+	p.multipartCopyPartSize = partSize
+}
+
+func (p *AWSToolsParams) SetClientEncryption(wrapper KeyWrapper) {
+	p.keyWrapper = wrapper
+}
+
+func (p *AWSToolsParams) SetUnencryptedReads(allow bool) {
+	p.unencryptedReads = allow
+}
+
+func (p *AWSToolsParams) SetMultipartUploadPartSize(partSize int64) {
+	p.uploadPartSize = partSize
+}
+
+func (p *AWSToolsParams) SetMultipartUploadConcurrency(concurrency int) {
+	p.uploadConcurrency = concurrency
+}
+
+func (p *AWSToolsParams) SetMultipartUploadLeavePartsOnError(leave bool) {
+	p.leavePartsOnError = leave
+}
+
+func (p *AWSToolsParams) SetMultipartUploadBufferProvider(provider manager.ReadSeekerWriteToProvider) {
+	p.uploadBufferProvider = provider
+}
+
+func (p *AWSToolsParams) SetMultipartDownloadPartSize(partSize int64) {
+	p.downloadPartSize = partSize
+}
+
+func (p *AWSToolsParams) SetMultipartDownloadConcurrency(concurrency int) {
+	p.downloadConcurrency = concurrency
+}
+
+func (p *AWSToolsParams) SetMultipartDownloadBufferProvider(provider manager.WriterReadFromProvider) {
+	p.downloadBufferProvider = provider
+}
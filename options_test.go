@@ -120,3 +120,41 @@ func TestSetAWSToolsParams(t *testing.T) {
 	}
 
 }
+
+func TestMultipartCopyParams(t *testing.T) {
+	params, err := newAWSToolsParams()
+	if err != nil {
+		t.Fatalf("newAWSToolsParams returned error: %v", err)
+	}
+
+	if params.MultipartCopyThreshold() != maxSingleCopySize {
+		t.Errorf("Expected default threshold %d, got %d", int64(maxSingleCopySize), params.MultipartCopyThreshold())
+	}
+
+	threshold := int64(100 * 1024 * 1024)
+	partSize := int64(64 * 1024 * 1024)
+
+	params, err = newAWSToolsParams(
+		WithMultipartCopyThreshold(threshold),
+		WithMultipartCopyPartSize(partSize),
+	)
+	if err != nil {
+		t.Fatalf("newAWSToolsParams returned error: %v", err)
+	}
+
+	if params.MultipartCopyThreshold() != threshold {
+		t.Errorf("Expected threshold %d, got %d", threshold, params.MultipartCopyThreshold())
+	}
+
+	if params.MultipartCopyPartSize() != partSize {
+		t.Errorf("Expected part size %d, got %d", partSize, params.MultipartCopyPartSize())
+	}
+
+	if _, err := newAWSToolsParams(WithMultipartCopyThreshold(6 * 1024 * 1024 * 1024)); err == nil {
+		t.Error("Expected error for threshold above 5 GiB")
+	}
+
+	if _, err := newAWSToolsParams(WithMultipartCopyPartSize(1024)); err == nil {
+		t.Error("Expected error for part size below 5 MiB")
+	}
+}
@@ -0,0 +1,301 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// KeyMapper returns the destination key for a source key.
+type KeyMapper func(srcKey string) string
+
+// PrefixProgress is reported after every key processed by CopyPrefix or
+// MovePrefix. Listed grows while the source is still being listed.
+type PrefixProgress struct {
+	Key     string
+	Listed  int64
+	Copied  int64
+	Skipped int64
+	Failed  int64
+}
+
+// PrefixFailure describes a key that could not be copied or moved.
+type PrefixFailure struct {
+	SrcKey string
+	DstKey string
+	Err    error
+}
+
+// PrefixResult summarizes a CopyPrefix or MovePrefix run.
+type PrefixResult struct {
+	Copied  int64
+	Skipped int64
+	Failed  []PrefixFailure
+}
+
+// PrefixOption customizes CopyPrefix and MovePrefix calls.
+type PrefixOption func(*prefixParams)
+
+type prefixParams struct {
+	concurrency  int
+	mapKey       KeyMapper
+	progress     func(PrefixProgress)
+	skipExisting bool
+	copyOpts     []CopyOption
+}
+
+// WithPrefixConcurrency sets how many keys are copied in parallel. It
+// defaults to the number of stream workers configured on AWSTools.
+func WithPrefixConcurrency(n int) PrefixOption {
+	return func(p *prefixParams) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithKeyMapper replaces the default mapping, which swaps the source prefix
+// for the destination prefix.
+func WithKeyMapper(mapKey KeyMapper) PrefixOption {
+	return func(p *prefixParams) {
+		p.mapKey = mapKey
+	}
+}
+
+// WithPrefixProgress registers a callback invoked after every key. Calls are
+// serialized, so the callback does not need to be safe for concurrent use.
+func WithPrefixProgress(fn func(PrefixProgress)) PrefixOption {
+	return func(p *prefixParams) {
+		p.progress = fn
+	}
+}
+
+// WithSkipExisting skips keys whose destination already exists with the
+// source size and ETag, which lets an interrupted run be resumed. Objects
+// copied in parts get a new ETag, so the run records the source ETag in
+// their awstools-source-etag metadata and compares it instead. Copies made
+// with WithCopyMetadata or another REPLACE directive keep exactly the given
+// metadata; their parts-copied destinations are copied again.
+func WithSkipExisting() PrefixOption {
+	return func(p *prefixParams) {
+		p.skipExisting = true
+	}
+}
+
+// WithPrefixCopyOptions applies opts to every object copy.
+func WithPrefixCopyOptions(opts ...CopyOption) PrefixOption {
+	return func(p *prefixParams) {
+		p.copyOpts = append(p.copyOpts, opts...)
+	}
+}
+
+func (a *AWSTools) newPrefixParams(srcPrefix, dstPrefix string, opts ...PrefixOption) *prefixParams {
+	p := &prefixParams{
+		concurrency: a.queueWorkers,
+		mapKey: func(srcKey string) string {
+			return dstPrefix + strings.TrimPrefix(srcKey, srcPrefix)
+		},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+	return p
+}
+
+// objectCopyOptions returns the options of every object copy. Runs that
+// skip existing keys record the source ETag of objects copied in parts.
+func (p *prefixParams) objectCopyOptions() []CopyOption {
+	if !p.skipExisting {
+		return p.copyOpts
+	}
+	return append(slices.Clip(p.copyOpts), func(cp *copyParams) {
+		cp.stampSourceETag = true
+	})
+}
+
+func (a *AWSTools) CopyPrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string, opts ...PrefixOption) (*PrefixResult, error) {
+	return a.CopyPrefixWithContext(context.Background(), srcBucket, srcPrefix, dstBucket, dstPrefix, opts...)
+}
+
+// CopyPrefixWithContext copies every object under srcPrefix to dstBucket.
+// Keys that fail are listed in the result and cause a non-nil error. Within
+// one bucket, the destination keys must not fall under srcPrefix, or the
+// copies would be listed and copied again.
+func (a *AWSTools) CopyPrefixWithContext(ctx context.Context, srcBucket, srcPrefix, dstBucket, dstPrefix string, opts ...PrefixOption) (*PrefixResult, error) {
+	params := a.newPrefixParams(srcPrefix, dstPrefix, opts...)
+	copyOpts := params.objectCopyOptions()
+
+	return a.runPrefix(ctx, srcBucket, srcPrefix, dstBucket, "copy", params,
+		func(ctx context.Context, srcKey, dstKey string) error {
+			_, err := a.CopyObjectWithContext(ctx, srcBucket, srcKey, dstBucket, dstKey, copyOpts...)
+			return err
+		}, nil)
+}
+
+func (a *AWSTools) MovePrefix(srcBucket, srcPrefix, dstBucket, dstPrefix string, opts ...PrefixOption) (*PrefixResult, error) {
+	return a.MovePrefixWithContext(context.Background(), srcBucket, srcPrefix, dstBucket, dstPrefix, opts...)
+}
+
+// MovePrefixWithContext moves every object under srcPrefix to dstBucket with
+// the same guarantees as MoveObject. With WithSkipExisting, sources whose
+// destination is already in place are deleted, finishing an interrupted move.
+func (a *AWSTools) MovePrefixWithContext(ctx context.Context, srcBucket, srcPrefix, dstBucket, dstPrefix string, opts ...PrefixOption) (*PrefixResult, error) {
+	params := a.newPrefixParams(srcPrefix, dstPrefix, opts...)
+	copyOpts := params.objectCopyOptions()
+
+	return a.runPrefix(ctx, srcBucket, srcPrefix, dstBucket, "move", params,
+		func(ctx context.Context, srcKey, dstKey string) error {
+			_, err := a.MoveObjectWithContext(ctx, srcBucket, srcKey, dstBucket, dstKey, copyOpts...)
+			return err
+		},
+		func(ctx context.Context, srcKey, etag string) error {
+			return a.deleteMovedSource(ctx, &ObjectInfo{Bucket: srcBucket, Key: srcKey, ETag: etag}, copyOpts...)
+		})
+}
+
+// runPrefix lists srcPrefix and runs transfer for every key on a bounded pool
+// of workers. When a key is skipped because its destination already matches,
+// the optional onSkip is called instead.
+func (a *AWSTools) runPrefix(ctx context.Context, srcBucket, srcPrefix, dstBucket, verb string, params *prefixParams,
+	transfer func(ctx context.Context, srcKey, dstKey string) error,
+	onSkip func(ctx context.Context, srcKey, etag string) error) (*PrefixResult, error) {
+	result := &PrefixResult{}
+	progress := PrefixProgress{}
+	objects := make(chan types.Object)
+	mu := &sync.Mutex{}
+
+	report := func(srcKey, dstKey string, skipped bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case err != nil:
+			result.Failed = append(result.Failed, PrefixFailure{SrcKey: srcKey, DstKey: dstKey, Err: err})
+			progress.Failed++
+		case skipped:
+			result.Skipped++
+			progress.Skipped++
+		default:
+			result.Copied++
+			progress.Copied++
+		}
+
+		if params.progress != nil {
+			progress.Key = srcKey
+			params.progress(progress)
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < params.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range objects {
+				srcKey := aws.ToString(obj.Key)
+				dstKey := params.mapKey(srcKey)
+				etag := trimETag(aws.ToString(obj.ETag))
+
+				if params.skipExisting {
+					done, err := a.destinationMatches(ctx, dstBucket, dstKey, aws.ToInt64(obj.Size), etag)
+					if err != nil {
+						report(srcKey, dstKey, false, err)
+						continue
+					}
+					if done {
+						if onSkip != nil {
+							err = onSkip(ctx, srcKey, etag)
+						}
+						report(srcKey, dstKey, true, err)
+						continue
+					}
+				}
+
+				report(srcKey, dstKey, false, transfer(ctx, srcKey, dstKey))
+			}
+		}()
+	}
+
+	listErr := a.listPrefix(ctx, srcBucket, srcPrefix, func(obj types.Object) error {
+		mu.Lock()
+		progress.Listed++
+		mu.Unlock()
+
+		select {
+		case objects <- obj:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(objects)
+	wg.Wait()
+
+	if listErr != nil {
+		return result, listErr
+	}
+	if len(result.Failed) > 0 {
+		first := result.Failed[0]
+		return result, fmt.Errorf("unable to %s %d of %d objects from bucket %q, first %q: %w",
+			verb, len(result.Failed), progress.Listed, srcBucket, first.SrcKey, first.Err)
+	}
+
+	return result, nil
+}
+
+// destinationMatches reports whether key exists in bucket as a copy of an
+// object with the given size and ETag.
+func (a *AWSTools) destinationMatches(ctx context.Context, bucket, key string, size int64, etag string) (bool, error) {
+	info, err := a.StatObjectWithContext(ctx, bucket, key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if info.Size != size {
+		return false, nil
+	}
+	return info.ETag == etag || info.Metadata[metaSourceETag] == etag, nil
+}
+
+// listPrefix calls fn for every object under prefix, page by page.
+func (a *AWSTools) listPrefix(ctx context.Context, bucket, prefix string, fn func(types.Object) error) error {
+	return a.listPages(ctx, bucket, prefix, func(objects []types.Object) error {
+		for _, obj := range objects {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// listPages calls fn with the objects of every page listed under prefix.
+func (a *AWSTools) listPages(ctx context.Context, bucket, prefix string, fn func([]types.Object) error) error {
+	paginator := s3.NewListObjectsV2Paginator(a.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to list items in bucket %q, %w", bucket, newOpError("ListObjectsV2", bucket, prefix, err))
+		}
+		if err := fn(page.Contents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
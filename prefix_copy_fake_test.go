@@ -0,0 +1,150 @@
+package awstools_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+func newPrefixTestTools(t *testing.T) (*awstools.AWSTools, *awstoolstest.Server) {
+	t.Helper()
+	tools, srv := awstoolstest.New(t,
+		awstools.WithMultipartCopyThreshold(6*1024*1024),
+		awstools.WithMultipartCopyPartSize(5*1024*1024))
+	srv.CreateBucket("bucket")
+	return tools, srv
+}
+
+func TestCopyPrefixKeyMapping(t *testing.T) {
+	tools, srv := newPrefixTestTools(t)
+	for _, key := range []string{"reports/a.csv", "reports/2024/b.csv", "other/c.csv"} {
+		srv.PutObject("bucket", key, []byte(key))
+	}
+
+	result, err := tools.CopyPrefix("bucket", "reports/", "bucket", "archive/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 2 || result.Skipped != 0 || len(result.Failed) != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if data, _ := srv.Object("bucket", "archive/2024/b.csv"); string(data) != "reports/2024/b.csv" {
+		t.Errorf("Unexpected copy: %q", data)
+	}
+
+	_, err = tools.CopyPrefix("bucket", "reports/", "other", "",
+		awstools.WithKeyMapper(strings.ToUpper))
+	if !errors.Is(err, awstools.ErrBucketNotFound) {
+		t.Errorf("Expected ErrBucketNotFound for a missing destination bucket, got %v", err)
+	}
+
+	srv.CreateBucket("other")
+	if _, err := tools.CopyPrefix("bucket", "reports/", "other", "",
+		awstools.WithKeyMapper(strings.ToUpper)); err != nil {
+		t.Fatal(err)
+	}
+	if keys := strings.Join(srv.Keys("other"), ","); keys != "REPORTS/2024/B.CSV,REPORTS/A.CSV" {
+		t.Errorf("Expected keys from the custom mapper, got %s", keys)
+	}
+}
+
+func TestCopyPrefixSkipExisting(t *testing.T) {
+	tools, srv := newPrefixTestTools(t)
+	big := bytes.Repeat([]byte("x"), 11*1024*1024)
+	srv.PutObject("bucket", "src/big", big)
+	srv.PutObject("bucket", "src/small", []byte("small"))
+
+	if _, err := tools.CopyPrefix("bucket", "src/", "bucket", "dst/", awstools.WithSkipExisting()); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := tools.StatObject("bucket", "src/big")
+	dst, _ := tools.StatObject("bucket", "dst/big")
+	if src.ETag == dst.ETag {
+		t.Fatal("Expected the big object to be copied in parts")
+	}
+
+	// Both destinations are in place, including the one copied in parts.
+	result, err := tools.CopyPrefix("bucket", "src/", "bucket", "dst/", awstools.WithSkipExisting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 0 || result.Skipped != 2 {
+		t.Errorf("Expected every key to be skipped, got %+v", result)
+	}
+
+	// A destination with other content is copied again.
+	srv.PutObject("bucket", "dst/small", []byte("SMALL"))
+	big[0] = 'y'
+	srv.PutObject("bucket", "src/big", big)
+	result, err = tools.CopyPrefix("bucket", "src/", "bucket", "dst/", awstools.WithSkipExisting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 2 || result.Skipped != 0 {
+		t.Errorf("Expected changed keys to be copied again, got %+v", result)
+	}
+	if data, _ := srv.Object("bucket", "dst/small"); string(data) != "small" {
+		t.Errorf("Expected the destination to be overwritten, got %q", data)
+	}
+}
+
+func TestMovePrefixResume(t *testing.T) {
+	tools, srv := newPrefixTestTools(t)
+	srv.PutObject("bucket", "src/big", bytes.Repeat([]byte("x"), 11*1024*1024))
+	srv.PutObject("bucket", "src/small", []byte("small"))
+
+	// An interrupted move left the copies without deleting the sources.
+	if _, err := tools.CopyPrefix("bucket", "src/", "bucket", "dst/", awstools.WithSkipExisting()); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tools.MovePrefix("bucket", "src/", "bucket", "dst/", awstools.WithSkipExisting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Skipped != 2 || result.Copied != 0 {
+		t.Errorf("Expected both keys to be skipped, got %+v", result)
+	}
+	if keys := strings.Join(srv.Keys("bucket"), ","); keys != "dst/big,dst/small" {
+		t.Errorf("Expected the sources to be deleted, got %s", keys)
+	}
+}
+
+func TestCopyPrefixFailures(t *testing.T) {
+	tools, srv := newPrefixTestTools(t)
+	for _, key := range []string{"src/a", "src/b", "src/c"} {
+		srv.PutObject("bucket", key, []byte(key))
+	}
+
+	var last awstools.PrefixProgress
+	result, err := tools.CopyPrefix("bucket", "src/", "bucket", "dst/",
+		awstools.WithPrefixConcurrency(1),
+		awstools.WithKeyMapper(func(key string) string {
+			if key == "src/b" {
+				return "" // an empty key is rejected before any request
+			}
+			return "dst/" + strings.TrimPrefix(key, "src/")
+		}),
+		awstools.WithPrefixProgress(func(p awstools.PrefixProgress) {
+			last = p
+		}))
+	if err == nil {
+		t.Fatal("Expected an error when a key fails")
+	}
+	if !strings.Contains(err.Error(), "1 of 3") || !strings.Contains(err.Error(), `"src/b"`) {
+		t.Errorf("Expected the error to summarize the failures, got %v", err)
+	}
+	if result.Copied != 2 || len(result.Failed) != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if failed := result.Failed[0]; failed.SrcKey != "src/b" || failed.DstKey != "" || failed.Err == nil {
+		t.Errorf("Unexpected failure: %+v", failed)
+	}
+	if last.Listed != 3 || last.Copied != 2 || last.Failed != 1 {
+		t.Errorf("Unexpected final progress: %+v", last)
+	}
+}
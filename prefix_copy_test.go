@@ -0,0 +1,58 @@
+package awstools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrefixParamsDefaultMapping(t *testing.T) {
+	tools, err := NewAWSTools(
+		WithAccessKeyID("test-key"),
+		WithSecretKey("test-secret"),
+		WithRegion("us-east-1"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create AWSTools: %v", err)
+	}
+
+	params := tools.newPrefixParams("reports/2024/", "archive/2024/")
+
+	if params.concurrency != tools.queueWorkers {
+		t.Errorf("Expected concurrency %d, got %d", tools.queueWorkers, params.concurrency)
+	}
+
+	if got := params.mapKey("reports/2024/jan/summary.csv"); got != "archive/2024/jan/summary.csv" {
+		t.Errorf("Unexpected mapped key: %s", got)
+	}
+}
+
+func TestPrefixParamsOptions(t *testing.T) {
+	tools, err := NewAWSTools(
+		WithAccessKeyID("test-key"),
+		WithSecretKey("test-secret"),
+		WithRegion("us-east-1"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create AWSTools: %v", err)
+	}
+
+	params := tools.newPrefixParams("src/", "dst/",
+		WithPrefixConcurrency(16),
+		WithKeyMapper(strings.ToUpper),
+		WithSkipExisting(),
+		WithPrefixCopyOptions(WithCopyContentType("text/plain")),
+	)
+
+	if params.concurrency != 16 {
+		t.Errorf("Expected concurrency 16, got %d", params.concurrency)
+	}
+	if got := params.mapKey("src/a.txt"); got != "SRC/A.TXT" {
+		t.Errorf("Expected custom mapper to be used, got %s", got)
+	}
+	if !params.skipExisting {
+		t.Error("Expected skipExisting to be set")
+	}
+	if len(params.copyOpts) != 1 {
+		t.Errorf("Expected 1 copy option, got %d", len(params.copyOpts))
+	}
+}
@@ -0,0 +1,156 @@
+package awstools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// PresignedPost holds what a browser needs to upload with an HTML form: the
+// form action URL and the fields to send, as hidden inputs, before the file.
+type PresignedPost struct {
+	URL     string
+	Fields  map[string]string
+	Expires time.Time
+}
+
+// PostPolicyOption adds a condition, and the matching form field when one is
+// needed, to a presigned POST policy.
+type PostPolicyOption func(*postPolicy)
+
+type postPolicy struct {
+	expiry     time.Duration
+	conditions []interface{}
+	fields     map[string]string
+}
+
+// WithPostExpiry sets how long the policy stays valid. It defaults to 15
+// minutes and cannot exceed 7 days.
+func WithPostExpiry(expiry time.Duration) PostPolicyOption {
+	return func(p *postPolicy) {
+		p.expiry = expiry
+	}
+}
+
+// WithPostKeyPrefix allows any key starting with prefix instead of only the
+// exact key given to PresignPost. The key form field may then use the
+// ${filename} placeholder, e.g. "uploads/${filename}".
+func WithPostKeyPrefix(prefix string) PostPolicyOption {
+	return func(p *postPolicy) {
+		p.conditions = append(p.conditions, []interface{}{"starts-with", "$key", prefix})
+	}
+}
+
+// WithPostContentLengthRange limits the uploaded file size, in bytes.
+func WithPostContentLengthRange(min, max int64) PostPolicyOption {
+	return func(p *postPolicy) {
+		p.conditions = append(p.conditions, []interface{}{"content-length-range", min, max})
+	}
+}
+
+// WithPostContentType requires the Content-Type form field to be contentType
+// and pre-fills it.
+func WithPostContentType(contentType string) PostPolicyOption {
+	return func(p *postPolicy) {
+		p.conditions = append(p.conditions, map[string]string{"Content-Type": contentType})
+		p.fields["Content-Type"] = contentType
+	}
+}
+
+// WithPostContentTypePrefix requires the Content-Type form field to start
+// with prefix, e.g. "image/". The form must send the field itself.
+func WithPostContentTypePrefix(prefix string) PostPolicyOption {
+	return func(p *postPolicy) {
+		p.conditions = append(p.conditions, []interface{}{"starts-with", "$Content-Type", prefix})
+	}
+}
+
+// WithPostSuccessRedirect redirects the browser to redirectURL after a
+// successful upload.
+func WithPostSuccessRedirect(redirectURL string) PostPolicyOption {
+	return func(p *postPolicy) {
+		p.conditions = append(p.conditions, map[string]string{"success_action_redirect": redirectURL})
+		p.fields["success_action_redirect"] = redirectURL
+	}
+}
+
+// WithPostSuccessStatus sets the status returned after a successful upload
+// when no redirect is configured: 200, 201 or 204.
+func WithPostSuccessStatus(status int) PostPolicyOption {
+	return func(p *postPolicy) {
+		value := strconv.Itoa(status)
+		p.conditions = append(p.conditions, map[string]string{"success_action_status": value})
+		p.fields["success_action_status"] = value
+	}
+}
+
+// WithPostMetadata stores metadata with the uploaded object and requires the
+// form to send it unchanged.
+func WithPostMetadata(metadata map[string]string) PostPolicyOption {
+	return func(p *postPolicy) {
+		for k, v := range metadata {
+			field := "x-amz-meta-" + strings.ToLower(k)
+			p.conditions = append(p.conditions, map[string]string{field: v})
+			p.fields[field] = v
+		}
+	}
+}
+
+func (a *AWSTools) PresignPost(bucket, key string, opts ...PostPolicyOption) (*PresignedPost, error) {
+	return a.PresignPostWithContext(context.Background(), bucket, key, opts...)
+}
+
+// PresignPostWithContext builds a signed POST policy for uploading key to
+// bucket from an HTML form. The form must use multipart/form-data, send
+// every entry of Fields, and send the file last in a field named "file".
+func (a *AWSTools) PresignPostWithContext(ctx context.Context, bucket, key string, opts ...PostPolicyOption) (*PresignedPost, error) {
+	policy := &postPolicy{
+		expiry: defaultPresignExpiry,
+		fields: make(map[string]string),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(policy)
+		}
+	}
+	if policy.expiry <= 0 || policy.expiry > maxPresignExpiry {
+		return nil, fmt.Errorf("presign expiry must be between 1s and %s, got %s", maxPresignExpiry, policy.expiry)
+	}
+
+	req, err := a.presigner.PresignPostObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, func(o *s3.PresignPostOptions) {
+		o.Expires = policy.expiry
+		o.Conditions = policy.conditions
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to presign POST for object %q in bucket %q, %w", key, bucket, err)
+	}
+
+	fields := make(map[string]string, len(req.Values)+len(policy.fields))
+	for k, v := range policy.fields {
+		fields[k] = v
+	}
+	for k, v := range req.Values {
+		fields[k] = v
+	}
+
+	// The SDK returns only scheme and host; with path-style addressing
+	// (custom endpoints) the bucket belongs in the path.
+	postURL := req.URL
+	if len(a.params.Endpoint()) != 0 {
+		postURL += "/" + bucket
+	}
+
+	return &PresignedPost{
+		URL:     postURL,
+		Fields:  fields,
+		Expires: time.Now().Add(policy.expiry),
+	}, nil
+}
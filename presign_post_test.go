@@ -0,0 +1,88 @@
+package awstools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPresignPost(t *testing.T) {
+	tools := newPresignTestTools(t)
+
+	post, err := tools.PresignPost("bucket", "uploads/${filename}",
+		WithPostExpiry(time.Hour),
+		WithPostKeyPrefix("uploads/"),
+		WithPostContentLengthRange(1, 10<<20),
+		WithPostContentTypePrefix("image/"),
+		WithPostSuccessStatus(201),
+		WithPostMetadata(map[string]string{"Owner": "alice"}),
+	)
+	if err != nil {
+		t.Fatalf("PresignPost failed: %v", err)
+	}
+
+	if post.URL != "http://localhost:9000/bucket" {
+		t.Errorf("Expected path-style form URL, got %s", post.URL)
+	}
+
+	for field, want := range map[string]string{
+		"key":                   "uploads/${filename}",
+		"success_action_status": "201",
+		"x-amz-meta-owner":      "alice",
+		"X-Amz-Algorithm":       "AWS4-HMAC-SHA256",
+	} {
+		if got := post.Fields[field]; got != want {
+			t.Errorf("Expected field %s=%q, got %q", field, want, got)
+		}
+	}
+	if _, ok := post.Fields["Content-Type"]; ok {
+		t.Error("Content-Type prefix condition must be left for the form to fill")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(post.Fields["policy"])
+	if err != nil {
+		t.Fatalf("Policy is not base64: %v", err)
+	}
+	var policy struct {
+		Expiration string        `json:"expiration"`
+		Conditions []interface{} `json:"conditions"`
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		t.Fatalf("Policy is not JSON: %v", err)
+	}
+
+	expected := []interface{}{
+		[]interface{}{"starts-with", "$key", "uploads/"},
+		[]interface{}{"content-length-range", float64(1), float64(10 << 20)},
+		[]interface{}{"starts-with", "$Content-Type", "image/"},
+		map[string]interface{}{"success_action_status": "201"},
+		map[string]interface{}{"x-amz-meta-owner": "alice"},
+	}
+	for _, cond := range expected {
+		if !containsCondition(policy.Conditions, cond) {
+			t.Errorf("Policy is missing condition %v: %s", cond, raw)
+		}
+	}
+	if containsCondition(policy.Conditions, map[string]interface{}{"key": "uploads/${filename}"}) {
+		t.Error("Exact key condition must not be added with a key prefix")
+	}
+}
+
+func TestPresignPostRejectsExpiry(t *testing.T) {
+	tools := newPresignTestTools(t)
+
+	if _, err := tools.PresignPost("bucket", "key", WithPostExpiry(8*24*time.Hour)); err == nil {
+		t.Fatal("Expected an error for an expiry above 7 days")
+	}
+}
+
+func containsCondition(conditions []interface{}, want interface{}) bool {
+	for _, cond := range conditions {
+		if reflect.DeepEqual(cond, want) {
+			return true
+		}
+	}
+	return false
+}
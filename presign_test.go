@@ -0,0 +1,149 @@
+package awstools
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPresignTestTools(t *testing.T) *AWSTools {
+	t.Helper()
+
+	tools, err := NewAWSTools(
+		WithAccessKeyID("minioadmin"),
+		WithSecretKey("minioadmin"),
+		WithRegion("us-east-1"),
+		WithEndpoint("http://localhost:9000"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create AWSTools: %v", err)
+	}
+	return tools
+}
+
+func TestPresignGet(t *testing.T) {
+	tools := newPresignTestTools(t)
+
+	req, err := tools.PresignGet("bucket", "dir/report 2024.pdf",
+		WithPresignExpiry(time.Hour),
+		WithResponseContentDisposition(`attachment; filename="report.pdf"`),
+	)
+	if err != nil {
+		t.Fatalf("PresignGet failed: %v", err)
+	}
+
+	if req.Method != http.MethodGet {
+		t.Errorf("Expected method GET, got %s", req.Method)
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		t.Fatalf("Invalid presigned URL: %v", err)
+	}
+
+	if u.Host != "localhost:9000" {
+		t.Errorf("Expected custom endpoint host, got %s", u.Host)
+	}
+	if u.Path != "/bucket/dir/report 2024.pdf" {
+		t.Errorf("Expected path-style URL, got %s", u.Path)
+	}
+
+	query := u.Query()
+	if query.Get("X-Amz-Expires") != "3600" {
+		t.Errorf("Expected X-Amz-Expires 3600, got %s", query.Get("X-Amz-Expires"))
+	}
+	if query.Get("response-content-disposition") != `attachment; filename="report.pdf"` {
+		t.Errorf("Unexpected response-content-disposition: %s", query.Get("response-content-disposition"))
+	}
+	if query.Get("X-Amz-Signature") == "" {
+		t.Error("Expected URL to be signed")
+	}
+	if time.Until(req.Expires) <= 59*time.Minute {
+		t.Errorf("Unexpected expiry %v", req.Expires)
+	}
+}
+
+func TestPresignPutConstraints(t *testing.T) {
+	tools := newPresignTestTools(t)
+
+	req, err := tools.PresignPut("bucket", "upload.png",
+		WithPresignContentType("image/png"),
+		WithPresignContentLength(1024),
+		WithPresignMetadata(map[string]string{"owner": "web"}),
+	)
+	if err != nil {
+		t.Fatalf("PresignPut failed: %v", err)
+	}
+
+	if req.Method != http.MethodPut {
+		t.Errorf("Expected method PUT, got %s", req.Method)
+	}
+	if req.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("Expected signed Content-Type image/png, got %v", req.Header)
+	}
+	if req.Header.Get("Content-Length") != "1024" {
+		t.Errorf("Expected signed Content-Length 1024, got %v", req.Header)
+	}
+	if req.Header.Get("X-Amz-Meta-Owner") != "web" {
+		t.Errorf("Expected signed metadata header, got %v", req.Header)
+	}
+	if req.Header.Get("Host") != "" {
+		t.Error("Expected Host to be dropped from the signed headers")
+	}
+
+	signed := strings.Split(mustQuery(t, req.URL).Get("X-Amz-SignedHeaders"), ";")
+	for _, h := range []string{"content-type", "content-length", "x-amz-meta-owner"} {
+		found := false
+		for _, s := range signed {
+			if s == h {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected %s to be signed, got %v", h, signed)
+		}
+	}
+}
+
+func TestPresignDeleteAndHead(t *testing.T) {
+	tools := newPresignTestTools(t)
+
+	del, err := tools.PresignDelete("bucket", "file.txt")
+	if err != nil {
+		t.Fatalf("PresignDelete failed: %v", err)
+	}
+	if del.Method != http.MethodDelete {
+		t.Errorf("Expected method DELETE, got %s", del.Method)
+	}
+	if mustQuery(t, del.URL).Get("X-Amz-Expires") != "900" {
+		t.Errorf("Expected default expiry of 15 minutes, got %s", del.URL)
+	}
+
+	head, err := tools.PresignHead("bucket", "file.txt")
+	if err != nil {
+		t.Fatalf("PresignHead failed: %v", err)
+	}
+	if head.Method != http.MethodHead {
+		t.Errorf("Expected method HEAD, got %s", head.Method)
+	}
+}
+
+func TestPresignInvalidExpiry(t *testing.T) {
+	tools := newPresignTestTools(t)
+
+	if _, err := tools.PresignGet("bucket", "file.txt", WithPresignExpiry(8*24*time.Hour)); err == nil {
+		t.Error("Expected error for expiry above 7 days")
+	}
+}
+
+func mustQuery(t *testing.T, rawURL string) url.Values {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("Invalid URL %q: %v", rawURL, err)
+	}
+	return u.Query()
+}
@@ -0,0 +1,275 @@
+package awstools
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// progressInterval is the minimum time between two progress events.
+const progressInterval = 200 * time.Millisecond
+
+// TransferProgress is a snapshot of a running upload, download, copy or
+// stream read.
+type TransferProgress struct {
+	Op             string // "upload", "download", "copy" or "stream"
+	Bucket         string
+	Key            string
+	Transferred    int64
+	Total          int64 // -1 while unknown
+	PartsCompleted int
+	Rate           float64       // average bytes per second since the start
+	ETA            time.Duration // 0 while the total or the rate is unknown
+	Done           bool
+}
+
+// ProgressFunc receives progress events. Calls are serialized and throttled
+// to one every 200ms, plus a last one with Done set when the transfer
+// succeeds, so the function may update a UI directly. It runs on the
+// transfer goroutines and should return quickly.
+type ProgressFunc func(TransferProgress)
+
+// progressTracker accumulates the progress of one transfer and emits
+// throttled events. A nil tracker ignores every call.
+type progressTracker struct {
+	mu       sync.Mutex
+	fn       ProgressFunc
+	state    TransferProgress
+	start    time.Time
+	lastEmit time.Time
+}
+
+func newProgressTracker(fn ProgressFunc, op, bucket, key string, total int64) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+	return &progressTracker{
+		fn:    fn,
+		state: TransferProgress{Op: op, Bucket: bucket, Key: key, Total: total},
+		start: time.Now(),
+	}
+}
+
+// add records n more bytes transferred.
+func (t *progressTracker) add(n int64) {
+	if t == nil || n == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.Transferred += n
+	t.emit(false)
+}
+
+// set moves the transferred count, e.g. when a body is rewound for a retry.
+// It emits nothing, since seeking to measure a body is not progress.
+func (t *progressTracker) set(n int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.Transferred = n
+}
+
+func (t *progressTracker) setTotal(total int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state.Total < 0 {
+		t.state.Total = total
+	}
+}
+
+func (t *progressTracker) partDone() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.PartsCompleted++
+	t.emit(false)
+}
+
+// finish emits the final event. Only the first call has an effect.
+func (t *progressTracker) finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state.Done {
+		return
+	}
+	t.state.Done = true
+	if t.state.Total < 0 {
+		t.state.Total = t.state.Transferred
+	}
+	t.emit(true)
+}
+
+// emit calls fn with the current state unless the last event is too recent.
+// The caller holds t.mu.
+func (t *progressTracker) emit(force bool) {
+	now := time.Now()
+	if !force && now.Sub(t.lastEmit) < progressInterval {
+		return
+	}
+	t.lastEmit = now
+
+	state := t.state
+	if elapsed := now.Sub(t.start).Seconds(); elapsed > 0 {
+		state.Rate = float64(state.Transferred) / elapsed
+	}
+	if state.Total > 0 && state.Rate > 0 && !state.Done {
+		remaining := float64(state.Total-state.Transferred) / state.Rate
+		state.ETA = time.Duration(remaining * float64(time.Second))
+	}
+	t.fn(state)
+}
+
+// countParts is an SDK API option that counts uploaded parts and downloaded
+// ranges. A downloaded range is complete once its body has been read, and its
+// Content-Range tells the object size.
+func (t *progressTracker) countParts(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("AWSToolsProgress",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+			middleware.InitializeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleInitialize(ctx, in)
+			if err != nil {
+				return out, metadata, err
+			}
+
+			switch result := out.Result.(type) {
+			case *s3.UploadPartOutput:
+				t.partDone()
+			case *s3.GetObjectOutput:
+				if total, ok := contentRangeTotal(aws.ToString(result.ContentRange)); ok {
+					t.setTotal(total)
+				}
+				result.Body = &partBody{ReadCloser: result.Body, done: t.partDone}
+			}
+			return out, metadata, err
+		}), middleware.After)
+}
+
+// clientOptions returns the client option installing countParts, or nothing
+// for a nil tracker.
+func (t *progressTracker) clientOptions() []func(*s3.Options) {
+	if t == nil {
+		return nil
+	}
+	return []func(*s3.Options){func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, t.countParts)
+	}}
+}
+
+// contentRangeTotal extracts the object size from a "bytes a-b/size" value.
+func contentRangeTotal(contentRange string) (int64, bool) {
+	_, size, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return 0, false
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	return total, err == nil
+}
+
+// partBody calls done once, when the body has been read to the end.
+type partBody struct {
+	io.ReadCloser
+	done func()
+	once sync.Once
+}
+
+func (b *partBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+// progressReader counts the bytes read through it.
+type progressReader struct {
+	io.Reader
+	tracker *progressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.tracker.add(int64(n))
+	return n, err
+}
+
+// progressReadSeeker is a progressReader over a seekable body. Seeking moves
+// the count, so a body rewound for a retry is counted again from the start.
+type progressReadSeeker struct {
+	progressReader
+}
+
+func (r *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.Reader.(io.Seeker).Seek(offset, whence)
+	if err == nil {
+		r.tracker.set(pos)
+	}
+	return pos, err
+}
+
+// newProgressReader wraps r so that reads are reported to tracker, keeping r
+// seekable when it is.
+func newProgressReader(r io.Reader, tracker *progressTracker) io.Reader {
+	if tracker == nil {
+		return r
+	}
+	if _, ok := r.(io.Seeker); ok {
+		return &progressReadSeeker{progressReader{Reader: r, tracker: tracker}}
+	}
+	return &progressReader{Reader: r, tracker: tracker}
+}
+
+// progressWriterAt counts the bytes written through it.
+type progressWriterAt struct {
+	io.WriterAt
+	tracker *progressTracker
+}
+
+func (w *progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.WriterAt.WriteAt(p, off)
+	w.tracker.add(int64(n))
+	return n, err
+}
+
+// readCloser pairs a wrapped body with the Close of the original one.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodySize returns the remaining length of a seekable body, or -1.
+func bodySize(body io.Reader) int64 {
+	s, ok := body.(io.Seeker)
+	if !ok {
+		return -1
+	}
+	cur, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	end, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err := s.Seek(cur, io.SeekStart); err != nil {
+		return -1
+	}
+	return end - cur
+}
@@ -0,0 +1,104 @@
+package awstools
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProgressTrackerThrottles(t *testing.T) {
+	var events []TransferProgress
+	tracker := newProgressTracker(func(p TransferProgress) {
+		events = append(events, p)
+	}, "upload", "bucket", "key", 1000)
+
+	for i := 0; i < 100; i++ {
+		tracker.add(10)
+	}
+	tracker.finish()
+	tracker.finish()
+
+	if len(events) != 2 {
+		t.Fatalf("Expected the first and the final event, got %d", len(events))
+	}
+	last := events[len(events)-1]
+	if !last.Done || last.Transferred != 1000 || last.Total != 1000 {
+		t.Errorf("Unexpected final event: %+v", last)
+	}
+	if last.Op != "upload" || last.Bucket != "bucket" || last.Key != "key" {
+		t.Errorf("Unexpected transfer identity: %+v", last)
+	}
+	if events[0].Done || events[0].ETA <= 0 {
+		t.Errorf("Expected an ETA while running: %+v", events[0])
+	}
+}
+
+func TestProgressTrackerUnknownTotal(t *testing.T) {
+	var last TransferProgress
+	tracker := newProgressTracker(func(p TransferProgress) { last = p }, "stream", "bucket", "key", -1)
+
+	tracker.add(5)
+	if last.Total != -1 || last.ETA != 0 {
+		t.Errorf("Expected unknown total and no ETA, got %+v", last)
+	}
+
+	tracker.add(7)
+	tracker.finish()
+	if last.Total != 12 {
+		t.Errorf("Expected the final total to be the bytes read, got %d", last.Total)
+	}
+}
+
+func TestNilProgressTracker(t *testing.T) {
+	var tracker *progressTracker
+	tracker.add(1)
+	tracker.partDone()
+	tracker.finish()
+
+	body := strings.NewReader("data")
+	if newProgressReader(body, tracker) != io.Reader(body) {
+		t.Error("Expected the body to be left unwrapped without a tracker")
+	}
+}
+
+func TestProgressReadSeeker(t *testing.T) {
+	var last TransferProgress
+	body := bytes.NewReader(make([]byte, 64))
+	tracker := newProgressTracker(func(p TransferProgress) { last = p }, "upload", "bucket", "key", bodySize(body))
+	r := newProgressReader(body, tracker)
+
+	if tracker.state.Total != 64 || tracker.state.Transferred != 0 {
+		t.Errorf("Measuring the body must not count as progress: %+v", tracker.state)
+	}
+
+	io.CopyN(io.Discard, r, 10)
+	r.(io.Seeker).Seek(0, io.SeekStart)
+	io.Copy(io.Discard, r)
+	tracker.finish()
+
+	if last.Transferred != 64 {
+		t.Errorf("Expected a rewound body to be counted once, got %d", last.Transferred)
+	}
+}
+
+func TestPartBody(t *testing.T) {
+	parts := 0
+	body := &partBody{ReadCloser: io.NopCloser(strings.NewReader("part")), done: func() { parts++ }}
+
+	io.ReadAll(body)
+	body.Read(make([]byte, 1))
+
+	if parts != 1 {
+		t.Errorf("Expected one completed part, got %d", parts)
+	}
+}
+
+func TestContentRangeTotal(t *testing.T) {
+	if total, ok := contentRangeTotal("bytes 0-99/12345"); !ok || total != 12345 {
+		t.Errorf("Expected 12345, got %d", total)
+	}
+	if _, ok := contentRangeTotal("bytes 0-99/*"); ok {
+		t.Error("Expected an unknown size to be rejected")
+	}
+}
@@ -0,0 +1,336 @@
+package awstools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// downloadState records which parts of a resumable download are already in
+// its .part file, with the CRC32C of their bytes. It is saved as JSON after
+// every part.
+type downloadState struct {
+	Bucket    string           `json:"bucket"`
+	Key       string           `json:"key"`
+	VersionID string           `json:"version_id,omitempty"`
+	ETag      string           `json:"etag"`
+	Size      int64            `json:"size"`
+	PartSize  int64            `json:"part_size"`
+	Completed map[int32]uint32 `json:"completed"`
+}
+
+// loadDownloadState reads the state file at path, returning nil when there
+// is none.
+func loadDownloadState(path string) (*downloadState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download state %q, %w", path, err)
+	}
+
+	state := &downloadState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid download state %q, %w", path, err)
+	}
+	if state.Completed == nil {
+		state.Completed = make(map[int32]uint32)
+	}
+	return state, nil
+}
+
+// save writes the state next to path and renames it into place.
+func (s *downloadState) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write download state %q, %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write download state %q, %w", path, err)
+	}
+	return nil
+}
+
+// matches reports whether the state belongs to the current revision of the
+// object described by info.
+func (s *downloadState) matches(info *ObjectInfo) bool {
+	return s.Bucket == info.Bucket && s.Key == info.Key && s.ETag == info.ETag && s.Size == info.Size
+}
+
+// verifyParts drops the completed parts whose bytes in file no longer have
+// the recorded checksum, so that they are downloaded again.
+func (s *downloadState) verifyParts(file io.ReaderAt) error {
+	for _, part := range planCopyParts(s.Size, s.PartSize) {
+		sum, ok := s.Completed[part.number]
+		if !ok {
+			continue
+		}
+		hash := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+		if _, err := io.Copy(hash, io.NewSectionReader(file, part.start, part.end-part.start+1)); err != nil {
+			return fmt.Errorf("failed to verify part %d, %w", part.number, err)
+		}
+		if hash.Sum32() != sum {
+			delete(s.Completed, part.number)
+		}
+	}
+	return nil
+}
+
+func (a *AWSTools) DownloadFileResumable(bucket, fileName, filePath string, opts ...DownloadOption) error {
+	return a.DownloadFileResumableWithContext(context.Background(), bucket, fileName, filePath, opts...)
+}
+
+// DownloadFileResumableWithContext downloads the object into filePath + ".part"
+// with parallel ranged GETs, recording the completed parts in
+// filePath + ".part.state". Running it again after an interruption fetches
+// only the missing ranges, as long as the object ETag is unchanged and the
+// .part file still has the object size; otherwise the download starts over.
+// Parts recorded as complete are checked against their CRC32C first and
+// fetched again when their bytes changed. Once every range is in place the
+// file is renamed to filePath.
+//
+// Every range is pinned to the ETag read at the start. Client-side encrypted
+// objects cannot be downloaded by range and are rejected.
+func (a *AWSTools) DownloadFileResumableWithContext(ctx context.Context, bucket, fileName, filePath string, opts ...DownloadOption) error {
+	params := newDownloadParams(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(fileName),
+	}, opts...)
+	if params.err != nil {
+		return params.err
+	}
+	input := params.input
+
+	info, err := a.statObject(ctx, &s3.HeadObjectInput{
+		Bucket:               input.Bucket,
+		Key:                  input.Key,
+		VersionId:            input.VersionId,
+		SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+		SSECustomerKey:       input.SSECustomerKey,
+		SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+	})
+	if err != nil {
+		return err
+	}
+	if isEncrypted(info.Metadata) {
+		return errors.New("resumable downloads do not support client-side encrypted objects")
+	}
+	if err := a.checkEnvelope(info.Metadata); err != nil {
+		return fmt.Errorf("unable to download object %q in bucket %q, %w", fileName, bucket, err)
+	}
+
+	var sums *objectChecksums
+	if input.ChecksumMode == types.ChecksumModeEnabled {
+		if sums, err = a.objectChecksums(ctx, input); err != nil {
+			return err
+		}
+		if trimETag(sums.etag) != info.ETag {
+			return fmt.Errorf("object %q in bucket %q changed while reading its checksums, %w", fileName, bucket, ErrPreconditionFailed)
+		}
+	}
+
+	partPath, statePath := filePath+".part", filePath+".part.state"
+
+	state, err := loadDownloadState(statePath)
+	if err != nil {
+		return err
+	}
+	if state != nil && !state.matches(info) {
+		// The object was rewritten; the bytes already downloaded are stale.
+		state = nil
+	}
+	if state != nil {
+		// Without the .part file, or with one of another size, the recorded
+		// parts are not on disk.
+		if stat, err := os.Stat(partPath); err != nil || stat.Size() != state.Size {
+			state = nil
+		}
+	}
+
+	flags := os.O_RDWR | os.O_CREATE
+	if state == nil {
+		partSize := params.partSize
+		if partSize <= 0 {
+			partSize = a.params.MultipartDownloadPartSize()
+		}
+		state = &downloadState{
+			Bucket:    bucket,
+			Key:       fileName,
+			VersionID: info.VersionID,
+			ETag:      info.ETag,
+			Size:      info.Size,
+			PartSize:  partSize,
+			Completed: make(map[int32]uint32),
+		}
+		flags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q, %w", partPath, err)
+	}
+	defer file.Close()
+
+	if err := state.verifyParts(file); err != nil {
+		return fmt.Errorf("failed to read file %q, %w", partPath, err)
+	}
+	if err := file.Truncate(state.Size); err != nil {
+		return fmt.Errorf("failed to allocate file %q, %w", partPath, err)
+	}
+	if err := state.save(statePath); err != nil {
+		return err
+	}
+
+	tracker := newProgressTracker(params.progress, "download", bucket, fileName, state.Size)
+	if err := a.downloadMissingRanges(ctx, file, state, statePath, input, params, tracker); err != nil {
+		return err
+	}
+
+	if sums != nil {
+		if err := verifyFile(file, sums); err != nil {
+			// A corrupt file cannot be resumed; start over next time.
+			os.Remove(statePath)
+			return fmt.Errorf("downloaded file %q failed verification, %w", partPath, err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file %q, %w", partPath, err)
+	}
+	if err := os.Rename(partPath, filePath); err != nil {
+		return fmt.Errorf("failed to rename %q to %q, %w", partPath, filePath, err)
+	}
+	if err := os.Remove(statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("download completed but its state %q could not be removed, %w", statePath, err)
+	}
+
+	tracker.finish()
+	return nil
+}
+
+// downloadMissingRanges fetches the parts not yet recorded in state
+// concurrently. A part is recorded only after its bytes were synced to file.
+func (a *AWSTools) downloadMissingRanges(ctx context.Context, file *os.File, state *downloadState, statePath string,
+	input *s3.GetObjectInput, params *downloadParams, tracker *progressTracker) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var pending []copyPart
+	var downloaded int64
+	for _, part := range planCopyParts(state.Size, state.PartSize) {
+		if _, ok := state.Completed[part.number]; ok {
+			downloaded += part.end - part.start + 1
+			continue
+		}
+		pending = append(pending, part)
+	}
+	tracker.set(downloaded)
+
+	concurrency := params.concurrency
+	if concurrency <= 0 {
+		concurrency = a.params.MultipartDownloadConcurrency()
+	}
+
+	jobs := make(chan copyPart)
+	mu := &sync.Mutex{}
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range jobs {
+				sum, err := a.downloadRange(ctx, file, state, input, part)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				if err := file.Sync(); err != nil {
+					fail(fmt.Errorf("failed to sync file, %w", err))
+					continue
+				}
+
+				mu.Lock()
+				state.Completed[part.number] = sum
+				err = state.save(statePath)
+				mu.Unlock()
+				if err != nil {
+					fail(err)
+					continue
+				}
+				tracker.add(part.end - part.start + 1)
+				tracker.partDone()
+			}
+		}()
+	}
+
+dispatch:
+	for _, part := range pending {
+		select {
+		case jobs <- part:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// downloadRange writes one part of the object at its offset in w and returns
+// the CRC32C of its bytes.
+func (a *AWSTools) downloadRange(ctx context.Context, w io.WriterAt, state *downloadState, input *s3.GetObjectInput, part copyPart) (uint32, error) {
+	out, err := a.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:               input.Bucket,
+		Key:                  input.Key,
+		VersionId:            optionalString(state.VersionID),
+		Range:                aws.String(fmt.Sprintf("bytes=%d-%d", part.start, part.end)),
+		IfMatch:              aws.String(`"` + state.ETag + `"`),
+		SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+		SSECustomerKey:       input.SSECustomerKey,
+		SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to download range %d-%d, %w",
+			part.start, part.end, newOpError("GetObject", state.Bucket, state.Key, err))
+	}
+	defer out.Body.Close()
+
+	size := part.end - part.start + 1
+	hash := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	n, err := io.Copy(io.NewOffsetWriter(w, part.start), io.TeeReader(io.LimitReader(out.Body, size), hash))
+	if err != nil {
+		return 0, fmt.Errorf("failed to download range %d-%d, %w", part.start, part.end, err)
+	}
+	if n != size {
+		return 0, fmt.Errorf("failed to download range %d-%d, got %d of %d bytes", part.start, part.end, n, size)
+	}
+	return hash.Sum32(), nil
+}
@@ -0,0 +1,153 @@
+package awstools_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+// rangeRecorder proxies a Server, recording the ranges requested by GETs and
+// refusing the range starting at failFrom, when set.
+type rangeRecorder struct {
+	mu       sync.Mutex
+	ranges   []string
+	failFrom string
+}
+
+func (r *rangeRecorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ranges := r.ranges
+	r.ranges = nil
+	slices.Sort(ranges)
+	return ranges
+}
+
+func newResumableTestTools(t *testing.T, data []byte) (*awstools.AWSTools, *rangeRecorder) {
+	t.Helper()
+	srv := awstoolstest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.PutObject("bucket", "big", data)
+
+	rec := &rangeRecorder{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if value := r.Header.Get("Range"); r.Method == http.MethodGet && value != "" {
+			rec.mu.Lock()
+			rec.ranges = append(rec.ranges, value)
+			fail := rec.failFrom != "" && strings.HasPrefix(value, "bytes="+rec.failFrom+"-")
+			rec.mu.Unlock()
+			if fail {
+				http.Error(w, "", http.StatusForbidden)
+				return
+			}
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(proxy.Close)
+
+	tools, err := awstools.NewAWSTools(append(srv.Options(), awstools.WithEndpoint(proxy.URL))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tools, rec
+}
+
+func TestDownloadResumableRecovery(t *testing.T) {
+	const mib = 1024 * 1024
+	data := make([]byte, 11*mib)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	all := []string{"bytes=0-5242879", "bytes=10485760-11534335", "bytes=5242880-10485759"}
+
+	tests := []struct {
+		name   string
+		damage func(t *testing.T, partPath string)
+		want   []string
+	}{
+		{
+			name:   "intact",
+			damage: func(*testing.T, string) {},
+			want:   all[1:],
+		},
+		{
+			name: "missing part file",
+			damage: func(t *testing.T, partPath string) {
+				if err := os.Remove(partPath); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: all,
+		},
+		{
+			name: "truncated part file",
+			damage: func(t *testing.T, partPath string) {
+				if err := os.Truncate(partPath, 5*mib); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: all,
+		},
+		{
+			name: "changed part bytes",
+			damage: func(t *testing.T, partPath string) {
+				file, err := os.OpenFile(partPath, os.O_WRONLY, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer file.Close()
+				if _, err := file.WriteAt([]byte("garbage"), 100); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: all,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools, rec := newResumableTestTools(t, data)
+			filePath := filepath.Join(t.TempDir(), "big")
+			opts := []awstools.DownloadOption{
+				awstools.WithDownloadPartSize(5 * mib),
+				awstools.WithDownloadConcurrency(1),
+			}
+
+			// The second part fails, leaving only the first one recorded.
+			rec.failFrom = "5242880"
+			if err := tools.DownloadFileResumable("bucket", "big", filePath, opts...); err == nil {
+				t.Fatal("Expected the interrupted download to fail")
+			}
+			rec.take()
+			rec.failFrom = ""
+
+			tt.damage(t, filePath+".part")
+			if err := tools.DownloadFileResumable("bucket", "big", filePath, opts...); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.take(); !slices.Equal(got, tt.want) {
+				t.Errorf("Expected ranges %v on resume, got %v", tt.want, got)
+			}
+
+			got, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Error("Expected the resumed file to match the object")
+			}
+			if _, err := os.Stat(filePath + ".part.state"); !os.IsNotExist(err) {
+				t.Errorf("Expected the state to be removed, got %v", err)
+			}
+		})
+	}
+}
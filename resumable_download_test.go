@@ -0,0 +1,40 @@
+package awstools
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadStateMatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.bin.part.state")
+
+	state := &downloadState{
+		Bucket:    "bucket",
+		Key:       "file.bin",
+		ETag:      "abc",
+		Size:      20 << 20,
+		PartSize:  8 << 20,
+		Completed: map[int32]uint32{1: 0x1234, 3: 0x5678},
+	}
+	if err := state.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadDownloadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Completed[3] != 0x5678 || loaded.PartSize != 8<<20 {
+		t.Errorf("Unexpected state after reload: %+v", loaded)
+	}
+
+	info := &ObjectInfo{Bucket: "bucket", Key: "file.bin", ETag: "abc", Size: 20 << 20}
+	if !loaded.matches(info) {
+		t.Error("Expected the state to match the unchanged object")
+	}
+
+	info.ETag = "def"
+	if loaded.matches(info) {
+		t.Error("Expected a new ETag to invalidate the state")
+	}
+}
@@ -0,0 +1,444 @@
+package awstools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// uploadState is the progress of a resumable upload. It is saved as JSON
+// after every part so that an interrupted upload continues where it stopped.
+type uploadState struct {
+	Bucket            string                  `json:"bucket"`
+	Key               string                  `json:"key"`
+	UploadID          string                  `json:"upload_id"`
+	FileSize          int64                   `json:"file_size"`
+	FileModTime       time.Time               `json:"file_mod_time"`
+	PartSize          int64                   `json:"part_size"`
+	ChecksumAlgorithm types.ChecksumAlgorithm `json:"checksum_algorithm,omitempty"`
+	Parts             map[int32]uploadedPart  `json:"parts"`
+}
+
+type uploadedPart struct {
+	ETag     string `json:"etag"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// loadUploadState reads the state file at path, returning nil when there is
+// none.
+func loadUploadState(path string) (*uploadState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload state %q, %w", path, err)
+	}
+
+	state := &uploadState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid upload state %q, %w", path, err)
+	}
+	return state, nil
+}
+
+// save writes the state next to path and renames it into place, so that a
+// crash never leaves a truncated state file.
+func (s *uploadState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write upload state %q, %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write upload state %q, %w", path, err)
+	}
+	return nil
+}
+
+// fileUnchanged reports whether the local file still has the size and
+// modification time it had when the upload started.
+func (s *uploadState) fileUnchanged(stat os.FileInfo) bool {
+	return s.FileSize == stat.Size() && s.FileModTime.Equal(stat.ModTime())
+}
+
+func (a *AWSTools) UploadFileResumable(bucket, fileName, filePath, statePath string, transfer UploadTransfer, opts ...UploadOption) error {
+	return a.UploadFileResumableWithContext(context.Background(), bucket, fileName, filePath, statePath, transfer, opts...)
+}
+
+// UploadFileResumableWithContext uploads filePath in parts, recording the
+// upload ID and the completed parts in statePath (filePath + ".s3upload" when
+// empty). Running it again after an interruption lists the parts S3 already
+// holds and sends only the missing ones. When the file changed in between,
+// the old upload is aborted and the file is sent again from the start. The
+// state file is removed once the upload completes.
+//
+// Options that apply to every part, such as WithUploadSSECustomerKey, must be
+// given again when resuming. The part size of transfer is only used when the
+// upload starts; a resumed upload keeps the part size of its state. Files
+// that fit in a single part are uploaded with
+// UploadFileToS3WithContextAndTransfer.
+func (a *AWSTools) UploadFileResumableWithContext(ctx context.Context, bucket, fileName, filePath, statePath string, transfer UploadTransfer, opts ...UploadOption) error {
+	if a.params.ClientEncryption() != nil {
+		return errors.New("resumable uploads do not support client-side encryption")
+	}
+	if statePath == "" {
+		statePath = filePath + ".s3upload"
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q, %w", filePath, err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %q, %w", filePath, err)
+	}
+
+	input := applyUploadOptions(&s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(fileName),
+		Body:   file,
+	}, opts)
+	if err := checkEncodedSSECustomerKey(input.SSECustomerKey); err != nil {
+		return err
+	}
+
+	partSize := transfer.PartSize
+	if partSize <= 0 {
+		partSize = a.params.MultipartUploadPartSize()
+	}
+
+	state, err := loadUploadState(statePath)
+	if err != nil {
+		return err
+	}
+
+	if state == nil && stat.Size() <= partSize {
+		return a.UploadFileToS3WithContextAndTransfer(ctx, bucket, fileName, filePath, transfer, opts...)
+	}
+
+	if state != nil && (state.Bucket != bucket || state.Key != fileName) {
+		return fmt.Errorf("upload state %q belongs to object %q in bucket %q", statePath, state.Key, state.Bucket)
+	}
+
+	if state != nil && !state.fileUnchanged(stat) {
+		// Parts of another revision of the file cannot be reused.
+		a.abortMultipartUpload(ctx, bucket, fileName, aws.String(state.UploadID))
+		state = nil
+	}
+
+	if state != nil {
+		state.Parts, err = a.listUploadedParts(ctx, state, input)
+		if errors.Is(err, ErrNotFound) {
+			// The upload expired or was aborted by a lifecycle rule.
+			state = nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if state == nil {
+		if state, err = a.createResumableUpload(ctx, input, stat, partSize); err != nil {
+			return err
+		}
+	}
+	if err := state.save(statePath); err != nil {
+		return err
+	}
+
+	tracker := newProgressTracker(transfer.Progress, "upload", bucket, fileName, state.FileSize)
+	if err := a.uploadMissingParts(ctx, file, state, statePath, input, transfer, tracker); err != nil {
+		return err
+	}
+
+	if err := a.completeResumableUpload(ctx, state, input); err != nil {
+		return err
+	}
+
+	tracker.finish()
+	if err := os.Remove(statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("upload completed but its state %q could not be removed, %w", statePath, err)
+	}
+	return nil
+}
+
+// createResumableUpload starts the multipart upload with the object settings
+// of input.
+func (a *AWSTools) createResumableUpload(ctx context.Context, input *s3.PutObjectInput, stat os.FileInfo, partSize int64) (*uploadState, error) {
+	bucket, key := aws.ToString(input.Bucket), aws.ToString(input.Key)
+
+	if err := fillSHA256Metadata(input); err != nil {
+		return nil, fmt.Errorf("failed to hash file, %w", err)
+	}
+
+	out, err := a.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:                    input.Bucket,
+		Key:                       input.Key,
+		ACL:                       input.ACL,
+		CacheControl:              input.CacheControl,
+		ContentDisposition:        input.ContentDisposition,
+		ContentEncoding:           input.ContentEncoding,
+		ContentLanguage:           input.ContentLanguage,
+		ContentType:               input.ContentType,
+		Expires:                   input.Expires,
+		Metadata:                  input.Metadata,
+		StorageClass:              input.StorageClass,
+		Tagging:                   input.Tagging,
+		ServerSideEncryption:      input.ServerSideEncryption,
+		SSEKMSKeyId:               input.SSEKMSKeyId,
+		SSEKMSEncryptionContext:   input.SSEKMSEncryptionContext,
+		BucketKeyEnabled:          input.BucketKeyEnabled,
+		SSECustomerAlgorithm:      input.SSECustomerAlgorithm,
+		SSECustomerKey:            input.SSECustomerKey,
+		SSECustomerKeyMD5:         input.SSECustomerKeyMD5,
+		ChecksumAlgorithm:         input.ChecksumAlgorithm,
+		ObjectLockMode:            input.ObjectLockMode,
+		ObjectLockRetainUntilDate: input.ObjectLockRetainUntilDate,
+		ObjectLockLegalHoldStatus: input.ObjectLockLegalHoldStatus,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to start upload of %q to bucket %q, %w",
+			key, bucket, newOpError("CreateMultipartUpload", bucket, key, err))
+	}
+
+	return &uploadState{
+		Bucket:            bucket,
+		Key:               key,
+		UploadID:          aws.ToString(out.UploadId),
+		FileSize:          stat.Size(),
+		FileModTime:       stat.ModTime(),
+		PartSize:          partSize,
+		ChecksumAlgorithm: input.ChecksumAlgorithm,
+		Parts:             map[int32]uploadedPart{},
+	}, nil
+}
+
+// listUploadedParts returns the parts S3 holds for the upload that can be
+// kept: they have the planned size and, when the state recorded them, the
+// same ETag.
+func (a *AWSTools) listUploadedParts(ctx context.Context, state *uploadState, input *s3.PutObjectInput) (map[int32]uploadedPart, error) {
+	planned := make(map[int32]int64)
+	for _, part := range planCopyParts(state.FileSize, state.PartSize) {
+		planned[part.number] = part.end - part.start + 1
+	}
+
+	done := make(map[int32]uploadedPart)
+	var marker *string
+	for {
+		out, err := a.s3Client.ListParts(ctx, &s3.ListPartsInput{
+			Bucket:               aws.String(state.Bucket),
+			Key:                  aws.String(state.Key),
+			UploadId:             aws.String(state.UploadID),
+			PartNumberMarker:     marker,
+			SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+			SSECustomerKey:       input.SSECustomerKey,
+			SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list parts of upload %q, %w",
+				state.UploadID, newOpError("ListParts", state.Bucket, state.Key, err))
+		}
+
+		for _, part := range out.Parts {
+			number, etag := aws.ToInt32(part.PartNumber), aws.ToString(part.ETag)
+			if size, ok := planned[number]; !ok || aws.ToInt64(part.Size) != size {
+				continue
+			}
+			if local, ok := state.Parts[number]; ok && local.ETag != etag {
+				continue
+			}
+			done[number] = uploadedPart{ETag: etag, Checksum: listedPartChecksum(part, state.ChecksumAlgorithm)}
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			return done, nil
+		}
+		marker = out.NextPartNumberMarker
+	}
+}
+
+// uploadMissingParts sends the parts not yet in state concurrently, saving
+// the state after each one.
+func (a *AWSTools) uploadMissingParts(ctx context.Context, file io.ReaderAt, state *uploadState, statePath string,
+	input *s3.PutObjectInput, transfer UploadTransfer, tracker *progressTracker) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var pending []copyPart
+	var uploaded int64
+	for _, part := range planCopyParts(state.FileSize, state.PartSize) {
+		if _, ok := state.Parts[part.number]; ok {
+			uploaded += part.end - part.start + 1
+			continue
+		}
+		pending = append(pending, part)
+	}
+	tracker.set(uploaded)
+
+	concurrency := transfer.Concurrency
+	if concurrency <= 0 {
+		concurrency = a.params.MultipartUploadConcurrency()
+	}
+
+	jobs := make(chan copyPart)
+	mu := &sync.Mutex{}
+	var firstErr error
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range jobs {
+				size := part.end - part.start + 1
+				out, err := a.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+					Bucket:               input.Bucket,
+					Key:                  input.Key,
+					UploadId:             aws.String(state.UploadID),
+					PartNumber:           aws.Int32(part.number),
+					Body:                 io.NewSectionReader(file, part.start, size),
+					ContentLength:        aws.Int64(size),
+					ChecksumAlgorithm:    state.ChecksumAlgorithm,
+					SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+					SSECustomerKey:       input.SSECustomerKey,
+					SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+				})
+				if err != nil {
+					fail(fmt.Errorf("unable to upload part %d of %q to bucket %q, %w",
+						part.number, state.Key, state.Bucket, newOpError("UploadPart", state.Bucket, state.Key, err)))
+					continue
+				}
+
+				mu.Lock()
+				state.Parts[part.number] = uploadedPart{
+					ETag:     aws.ToString(out.ETag),
+					Checksum: uploadPartChecksum(out, state.ChecksumAlgorithm),
+				}
+				err = state.save(statePath)
+				mu.Unlock()
+				if err != nil {
+					fail(err)
+					continue
+				}
+
+				tracker.add(size)
+				tracker.partDone()
+			}
+		}()
+	}
+
+dispatch:
+	for _, part := range pending {
+		select {
+		case jobs <- part:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// completeResumableUpload assembles the parts recorded in state.
+func (a *AWSTools) completeResumableUpload(ctx context.Context, state *uploadState, input *s3.PutObjectInput) error {
+	completed := make([]types.CompletedPart, 0, len(state.Parts))
+	for number, part := range state.Parts {
+		completed = append(completed, completedPart(number, part, state.ChecksumAlgorithm))
+	}
+	sort.Slice(completed, func(i, j int) bool {
+		return aws.ToInt32(completed[i].PartNumber) < aws.ToInt32(completed[j].PartNumber)
+	})
+
+	_, err := a.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:               input.Bucket,
+		Key:                  input.Key,
+		UploadId:             aws.String(state.UploadID),
+		MultipartUpload:      &types.CompletedMultipartUpload{Parts: completed},
+		SSECustomerAlgorithm: input.SSECustomerAlgorithm,
+		SSECustomerKey:       input.SSECustomerKey,
+		SSECustomerKeyMD5:    input.SSECustomerKeyMD5,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to complete upload of %q to bucket %q, %w",
+			state.Key, state.Bucket, newOpError("CompleteMultipartUpload", state.Bucket, state.Key, err))
+	}
+	return nil
+}
+
+func completedPart(number int32, part uploadedPart, algorithm types.ChecksumAlgorithm) types.CompletedPart {
+	completed := types.CompletedPart{
+		PartNumber: aws.Int32(number),
+		ETag:       aws.String(part.ETag),
+	}
+	checksum := optionalString(part.Checksum)
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32c:
+		completed.ChecksumCRC32C = checksum
+	case types.ChecksumAlgorithmCrc32:
+		completed.ChecksumCRC32 = checksum
+	case types.ChecksumAlgorithmSha256:
+		completed.ChecksumSHA256 = checksum
+	case types.ChecksumAlgorithmSha1:
+		completed.ChecksumSHA1 = checksum
+	}
+	return completed
+}
+
+func uploadPartChecksum(out *s3.UploadPartOutput, algorithm types.ChecksumAlgorithm) string {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32c:
+		return aws.ToString(out.ChecksumCRC32C)
+	case types.ChecksumAlgorithmCrc32:
+		return aws.ToString(out.ChecksumCRC32)
+	case types.ChecksumAlgorithmSha256:
+		return aws.ToString(out.ChecksumSHA256)
+	case types.ChecksumAlgorithmSha1:
+		return aws.ToString(out.ChecksumSHA1)
+	default:
+		return ""
+	}
+}
+
+func listedPartChecksum(part types.Part, algorithm types.ChecksumAlgorithm) string {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32c:
+		return aws.ToString(part.ChecksumCRC32C)
+	case types.ChecksumAlgorithmCrc32:
+		return aws.ToString(part.ChecksumCRC32)
+	case types.ChecksumAlgorithmSha256:
+		return aws.ToString(part.ChecksumSHA256)
+	case types.ChecksumAlgorithmSha1:
+		return aws.ToString(part.ChecksumSHA1)
+	default:
+		return ""
+	}
+}
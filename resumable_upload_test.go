@@ -0,0 +1,82 @@
+package awstools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestUploadStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload.s3upload")
+
+	if state, err := loadUploadState(path); err != nil || state != nil {
+		t.Fatalf("Expected no state for a missing file, got %v, %v", state, err)
+	}
+
+	state := &uploadState{
+		Bucket:            "bucket",
+		Key:               "big.iso",
+		UploadID:          "upload-1",
+		FileSize:          12 << 20,
+		FileModTime:       time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		PartSize:          5 << 20,
+		ChecksumAlgorithm: types.ChecksumAlgorithmCrc32c,
+		Parts:             map[int32]uploadedPart{2: {ETag: `"etag-2"`, Checksum: "abc="}},
+	}
+	if err := state.save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Expected the temporary state file to be renamed")
+	}
+
+	loaded, err := loadUploadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.UploadID != "upload-1" || loaded.Parts[2].ETag != `"etag-2"` || !loaded.FileModTime.Equal(state.FileModTime) {
+		t.Errorf("Unexpected state after reload: %+v", loaded)
+	}
+}
+
+func TestUploadStateFileUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &uploadState{FileSize: stat.Size(), FileModTime: stat.ModTime()}
+	if !state.fileUnchanged(stat) {
+		t.Error("Expected the file to be unchanged")
+	}
+
+	later := stat.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if stat, _ = os.Stat(path); state.fileUnchanged(stat) {
+		t.Error("Expected a new modification time to be detected")
+	}
+}
+
+func TestCompletedPart(t *testing.T) {
+	part := completedPart(3, uploadedPart{ETag: `"etag"`, Checksum: "c2hh"}, types.ChecksumAlgorithmSha256)
+	if aws.ToInt32(part.PartNumber) != 3 || aws.ToString(part.ETag) != `"etag"` {
+		t.Errorf("Unexpected completed part: %+v", part)
+	}
+	if aws.ToString(part.ChecksumSHA256) != "c2hh" || part.ChecksumCRC32C != nil {
+		t.Error("Expected only the SHA256 checksum to be set")
+	}
+
+	if part := completedPart(1, uploadedPart{ETag: `"etag"`}, ""); part.ChecksumCRC32C != nil || part.ChecksumSHA256 != nil {
+		t.Error("Expected no checksum without an algorithm")
+	}
+}
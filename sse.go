@@ -0,0 +1,74 @@
+package awstools
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// sseCustomerAlgorithm is the only algorithm S3 accepts for SSE-C keys.
+const sseCustomerAlgorithm = "AES256"
+
+// sseCustomerKeySize is the size in bytes of the 256-bit keys S3 accepts.
+const sseCustomerKeySize = 32
+
+// ErrInvalidSSECustomerKey is returned, before any request is made, when an
+// SSE-C key is not 256 bits long.
+var ErrInvalidSSECustomerKey = errors.New("awstools: invalid SSE-C key")
+
+// sseCustomerKey holds the header values S3 expects for a customer-provided
+// encryption key. The SDK sends them as given, so the key is base64 encoded
+// and its MD5 digest computed here.
+type sseCustomerKey struct {
+	algorithm *string
+	key       *string
+	keyMD5    *string
+}
+
+// newSSECustomerKey prepares a 256-bit SSE-C key. S3 rejects keys of any
+// other length, which checkSSECustomerKey reports up front.
+func newSSECustomerKey(key []byte) sseCustomerKey {
+	sum := md5.Sum(key)
+	return sseCustomerKey{
+		algorithm: aws.String(sseCustomerAlgorithm),
+		key:       aws.String(base64.StdEncoding.EncodeToString(key)),
+		keyMD5:    aws.String(base64.StdEncoding.EncodeToString(sum[:])),
+	}
+}
+
+// checkSSECustomerKey fails with ErrInvalidSSECustomerKey unless key is 256
+// bits long.
+func checkSSECustomerKey(key []byte) error {
+	if len(key) != sseCustomerKeySize {
+		return fmt.Errorf("%w: got %d bytes, want %d", ErrInvalidSSECustomerKey, len(key), sseCustomerKeySize)
+	}
+	return nil
+}
+
+// checkEncodedSSECustomerKey checks a base64 SSE-C key as set on a request
+// input, which may also come from a custom option. A nil key is valid.
+func checkEncodedSSECustomerKey(encoded *string) error {
+	if encoded == nil {
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(*encoded)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSSECustomerKey, err)
+	}
+	return checkSSECustomerKey(key)
+}
+
+// encodeKMSContext encodes an SSE-KMS encryption context as the base64 JSON
+// object expected by the x-amz-server-side-encryption-context header.
+func encodeKMSContext(encryptionContext map[string]string) *string {
+	if len(encryptionContext) == 0 {
+		return nil
+	}
+	// Marshalling a map of strings cannot fail.
+	raw, _ := json.Marshal(encryptionContext)
+	return aws.String(base64.StdEncoding.EncodeToString(raw))
+}
@@ -0,0 +1,165 @@
+package awstools
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestSSECustomerKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	sum := md5.Sum(key)
+
+	input := &s3.PutObjectInput{}
+	WithUploadSSECustomerKey(key)(input)
+
+	if aws.ToString(input.SSECustomerAlgorithm) != "AES256" {
+		t.Errorf("Expected AES256, got %q", aws.ToString(input.SSECustomerAlgorithm))
+	}
+	if aws.ToString(input.SSECustomerKey) != base64.StdEncoding.EncodeToString(key) {
+		t.Errorf("Expected base64 encoded key, got %q", aws.ToString(input.SSECustomerKey))
+	}
+	if aws.ToString(input.SSECustomerKeyMD5) != base64.StdEncoding.EncodeToString(sum[:]) {
+		t.Errorf("Expected base64 MD5 of the key, got %q", aws.ToString(input.SSECustomerKeyMD5))
+	}
+
+	get := newDownloadParams(&s3.GetObjectInput{}, WithDownloadSSECustomerKey(key)).input
+	if aws.ToString(get.SSECustomerKey) != aws.ToString(input.SSECustomerKey) ||
+		aws.ToString(get.SSECustomerKeyMD5) != aws.ToString(input.SSECustomerKeyMD5) {
+		t.Error("Download key headers do not match the upload ones")
+	}
+
+	copyInput := newCopyParams(&s3.CopyObjectInput{},
+		WithCopySourceSSECustomerKey(key),
+		WithCopySSECustomerKey(bytes.Repeat([]byte{0x24}, 32)),
+	).input
+	if aws.ToString(copyInput.CopySourceSSECustomerKey) != aws.ToString(input.SSECustomerKey) {
+		t.Error("Copy source key does not match the upload key")
+	}
+	if aws.ToString(copyInput.SSECustomerKey) == aws.ToString(copyInput.CopySourceSSECustomerKey) {
+		t.Error("Destination key must be independent of the source key")
+	}
+}
+
+func TestSSECustomerKeyLength(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	tools, err := NewAWSTools(
+		WithAccessKeyID("test-key"),
+		WithSecretKey("test-secret"),
+		WithRegion("us-east-1"),
+		WithEndpoint(srv.URL),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create AWSTools: %v", err)
+	}
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	if err := os.WriteFile(filePath, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	short := []byte("too short")
+
+	calls := map[string]func() error{
+		"upload": func() error {
+			return tools.UploadFileToS3WithOptions("bucket", "key", filePath, WithUploadSSECustomerKey(short))
+		},
+		"resumable upload": func() error {
+			return tools.UploadFileResumable("bucket", "key", filePath, "", UploadTransfer{}, WithUploadSSECustomerKey(short))
+		},
+		"download": func() error {
+			return tools.DownloadFileFromS3WithOptions("bucket", "key", filepath.Join(dir, "out"), WithDownloadSSECustomerKey(short))
+		},
+		"resumable download": func() error {
+			return tools.DownloadFileResumable("bucket", "key", filepath.Join(dir, "out"), WithDownloadSSECustomerKey(short))
+		},
+		"range": func() error {
+			_, err := tools.GetObjectRange("bucket", "key", 0, 1, WithDownloadSSECustomerKey(short))
+			return err
+		},
+		"open": func() error {
+			_, err := tools.OpenObject("bucket", "key", WithDownloadSSECustomerKey(short))
+			return err
+		},
+		"stream": func() error {
+			return <-tools.ReadFileStreamFromS3WithOptions("bucket", "key", func(string) error { return nil }, WithDownloadSSECustomerKey(short))
+		},
+		"copy destination": func() error {
+			_, err := tools.CopyObject("bucket", "key", "bucket", "copy", WithCopySSECustomerKey(short))
+			return err
+		},
+		"copy source": func() error {
+			_, err := tools.CopyObjectTo(tools, "bucket", "key", "bucket", "copy", WithCopySourceSSECustomerKey(short))
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrInvalidSSECustomerKey) {
+			t.Errorf("%s: expected ErrInvalidSSECustomerKey, got %v", name, err)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("Expected no requests, got %d", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be created, got %v", err)
+	}
+}
+
+func TestSSEKMSOptions(t *testing.T) {
+	input := &s3.PutObjectInput{}
+	for _, opt := range []UploadOption{
+		WithUploadSSEKMS("arn:aws:kms:us-east-1:123456789012:key/abc"),
+		WithUploadSSEKMSContext(map[string]string{"department": "finance"}),
+		WithUploadBucketKey(true),
+	} {
+		opt(input)
+	}
+
+	if input.ServerSideEncryption != types.ServerSideEncryptionAwsKms {
+		t.Errorf("Expected aws:kms, got %s", input.ServerSideEncryption)
+	}
+	if aws.ToString(input.SSEKMSKeyId) != "arn:aws:kms:us-east-1:123456789012:key/abc" {
+		t.Errorf("Unexpected KMS key: %q", aws.ToString(input.SSEKMSKeyId))
+	}
+	if !aws.ToBool(input.BucketKeyEnabled) {
+		t.Error("Expected bucket key to be enabled")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(aws.ToString(input.SSEKMSEncryptionContext))
+	if err != nil {
+		t.Fatalf("Encryption context is not base64: %v", err)
+	}
+	var encryptionContext map[string]string
+	if err := json.Unmarshal(raw, &encryptionContext); err != nil || encryptionContext["department"] != "finance" {
+		t.Errorf("Unexpected encryption context: %s", raw)
+	}
+
+	input = &s3.PutObjectInput{}
+	WithUploadSSEKMS("")(input)
+	if input.SSEKMSKeyId != nil {
+		t.Error("Expected no key ID for the AWS managed key")
+	}
+	if encodeKMSContext(nil) != nil {
+		t.Error("Expected no header for an empty encryption context")
+	}
+}
@@ -0,0 +1,172 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectStore is a flat key/value store of objects with metadata. S3Store
+// keeps the objects in a bucket and LocalStore in a directory, with the same
+// semantics, so code written against ObjectStore can switch between them by
+// configuration:
+//
+//   - Put replaces the object and its metadata. The content headers and
+//     metadata of the UploadOptions are stored with it; metadata keys are
+//     lowercased. Other options may be ignored by stores that cannot honor
+//     them.
+//   - Get, Stat and Copy of a missing key fail with an error matching
+//     ErrNotFound.
+//   - List yields the objects under prefix in lexicographic key order, with
+//     Key, Size, ETag and LastModified set. Sizes in List and Stat are those
+//     of the content Get returns.
+//   - Delete of a missing key succeeds.
+//   - Copy copies the content and the metadata.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	List(ctx context.Context, prefix string) iter.Seq2[*ObjectInfo, error]
+	Delete(ctx context.Context, key string) error
+	Copy(ctx context.Context, srcKey, dstKey string) error
+}
+
+var (
+	_ ObjectStore = (*S3Store)(nil)
+	_ ObjectStore = (*LocalStore)(nil)
+)
+
+// S3Store is an ObjectStore over the objects of a bucket under a prefix.
+// Keys are relative to the prefix. Objects are encrypted and decrypted when
+// client-side encryption is configured on AWSTools.
+type S3Store struct {
+	a      *AWSTools
+	bucket string
+	prefix string
+}
+
+// Store returns the ObjectStore rooted at prefix in bucket. A non-empty
+// prefix is used as a directory, i.e. it is followed by "/".
+func (a *AWSTools) Store(bucket, prefix string) *S3Store {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &S3Store{a: a, bucket: bucket, prefix: prefix}
+}
+
+// Put uploads r, using multipart uploads with the settings of the AWSTools
+// instance for large bodies.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, opts ...UploadOption) error {
+	return s.a.upload(ctx, applyUploadOptions(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+		Body:   r,
+	}, opts), UploadTransfer{})
+}
+
+// Get returns the content of the object, which the caller must close, and
+// its metadata.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	fullKey := s.prefix + key
+
+	out, err := s.a.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get object %q in bucket %q, %w", fullKey, s.bucket, newOpError("GetObject", s.bucket, fullKey, err))
+	}
+
+	body, err := s.a.plaintextBody(ctx, out)
+	if err != nil {
+		out.Body.Close()
+		return nil, nil, fmt.Errorf("failed to decrypt object %q in bucket %q, %w", fullKey, s.bucket, err)
+	}
+
+	info := newObjectInfo(s.bucket, key, &s3.HeadObjectOutput{
+		ContentLength:        out.ContentLength,
+		ETag:                 out.ETag,
+		ContentType:          out.ContentType,
+		ContentEncoding:      out.ContentEncoding,
+		ContentDisposition:   out.ContentDisposition,
+		ContentLanguage:      out.ContentLanguage,
+		CacheControl:         out.CacheControl,
+		Metadata:             out.Metadata,
+		LastModified:         out.LastModified,
+		StorageClass:         out.StorageClass,
+		VersionId:            out.VersionId,
+		ServerSideEncryption: out.ServerSideEncryption,
+		SSEKMSKeyId:          out.SSEKMSKeyId,
+		SSECustomerAlgorithm: out.SSECustomerAlgorithm,
+		BucketKeyEnabled:     out.BucketKeyEnabled,
+	})
+	info.Size = plaintextSize(info)
+
+	return readCloser{body, out.Body}, info, nil
+}
+
+// Stat returns the metadata of the object. The size of client-side
+// encrypted objects is the size of their plaintext.
+func (s *S3Store) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := s.a.StatObjectWithContext(ctx, s.bucket, s.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	info.Key = key
+	info.Size = plaintextSize(info)
+	return info, nil
+}
+
+// List iterates over the objects under prefix, fetching pages as the
+// iteration advances. Sizes are those of the plaintext, as in Stat; with
+// client-side encryption configured the objects of each page are stated in
+// parallel to read them.
+func (s *S3Store) List(ctx context.Context, prefix string) iter.Seq2[*ObjectInfo, error] {
+	return func(yield func(*ObjectInfo, error) bool) {
+		errStop := errors.New("stop")
+
+		err := s.a.listPages(ctx, s.bucket, s.prefix+prefix, func(objects []types.Object) error {
+			sizes, err := s.a.listedSizes(ctx, s.bucket, objects)
+			if err != nil {
+				return err
+			}
+			for i, object := range objects {
+				if sizes[i] < 0 {
+					continue // deleted since the listing
+				}
+				if !yield(&ObjectInfo{
+					Bucket:       s.bucket,
+					Key:          strings.TrimPrefix(aws.ToString(object.Key), s.prefix),
+					Size:         sizes[i],
+					ETag:         trimETag(aws.ToString(object.ETag)),
+					LastModified: aws.ToTime(object.LastModified),
+					StorageClass: types.StorageClass(object.StorageClass),
+				}, nil) {
+					return errStop
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStop) {
+			yield(nil, err)
+		}
+	}
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.a.DeleteFileInS3WithContext(ctx, s.bucket, s.prefix+key)
+}
+
+// Copy copies the object server side, with multipart copies for large
+// objects.
+func (s *S3Store) Copy(ctx context.Context, srcKey, dstKey string) error {
+	_, err := s.a.CopyObjectWithContext(ctx, s.bucket, s.prefix+srcKey, s.bucket, s.prefix+dstKey)
+	return err
+}
@@ -0,0 +1,127 @@
+package awstools_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+func TestObjectStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	objects := map[string][]byte{
+		"docs/a.txt": []byte("hello"),
+		"docs/b.bin": bytes.Repeat([]byte("b"), 200*1024),
+		"docs/empty": {},
+	}
+
+	tests := []struct {
+		name  string
+		store func(t *testing.T) awstools.ObjectStore
+	}{
+		{
+			name: "s3",
+			store: func(t *testing.T) awstools.ObjectStore {
+				tools, srv := awstoolstest.New(t)
+				srv.CreateBucket("bucket")
+				return tools.Store("bucket", "data")
+			},
+		},
+		{
+			name: "s3 client encryption",
+			store: func(t *testing.T) awstools.ObjectStore {
+				tools, _ := newEncryptedTestTools(t)
+				return tools.Store("bucket", "data")
+			},
+		},
+		{
+			name: "local",
+			store: func(t *testing.T) awstools.ObjectStore {
+				store, err := awstools.NewLocalStore(t.TempDir())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return store
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store(t)
+			for key, data := range objects {
+				if err := store.Put(ctx, key, bytes.NewReader(data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for key, data := range objects {
+				if got := readStoreObject(t, store, key); !bytes.Equal(got, data) {
+					t.Errorf("%s: Get returned other content", key)
+				}
+				info, err := store.Stat(ctx, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Key != key || info.Size != int64(len(data)) {
+					t.Errorf("%s: unexpected Stat %q of size %d", key, info.Key, info.Size)
+				}
+			}
+
+			listed := 0
+			for info, err := range store.List(ctx, "docs/") {
+				if err != nil {
+					t.Fatal(err)
+				}
+				listed++
+				data, ok := objects[info.Key]
+				if !ok {
+					t.Errorf("Unexpected key %q", info.Key)
+					continue
+				}
+				if info.Size != int64(len(data)) {
+					t.Errorf("%s: List reported size %d, expected %d", info.Key, info.Size, len(data))
+				}
+			}
+			if listed != len(objects) {
+				t.Errorf("Expected %d objects, listed %d", len(objects), listed)
+			}
+		})
+	}
+}
+
+func TestS3StoreListStatsInParallel(t *testing.T) {
+	tools, _, rec := newListingTestTools(t, 3)
+	store := tools.Store("bucket", "data")
+	for i := range 9 {
+		content := strings.Repeat("x", i*10)
+		if err := store.Put(context.Background(), fmt.Sprintf("file-%02d", i), strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec.total = 0
+
+	var n int
+	for info, err := range store.List(context.Background(), "") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := int64(n * 10); info.Size != want {
+			t.Errorf("%s: expected size %d, got %d", info.Key, want, info.Size)
+		}
+		n++
+	}
+	if n != 9 {
+		t.Errorf("Expected 9 objects, got %d", n)
+	}
+	if rec.total != 9 {
+		t.Errorf("Expected 9 HEAD requests, got %d", rec.total)
+	}
+	if rec.peak < 2 || rec.peak > 3 {
+		t.Errorf("Expected between 2 and 3 HEAD requests in flight, got %d", rec.peak)
+	}
+}
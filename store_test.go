@@ -0,0 +1,13 @@
+package awstools
+
+import "testing"
+
+func TestS3StorePrefix(t *testing.T) {
+	a := &AWSTools{}
+	if s := a.Store("bucket", "data"); s.prefix != "data/" {
+		t.Errorf("Unexpected prefix: %q", s.prefix)
+	}
+	if s := a.Store("bucket", ""); s.prefix != "" {
+		t.Errorf("Expected no prefix at the bucket root, got %q", s.prefix)
+	}
+}
@@ -0,0 +1,117 @@
+package awstools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func (a *AWSTools) GetObjectTags(bucket, key string) (map[string]string, error) {
+	return a.GetObjectTagsWithContext(context.Background(), bucket, key)
+}
+
+func (a *AWSTools) GetObjectTagsWithContext(ctx context.Context, bucket, key string) (map[string]string, error) {
+	tagSet, err := a.getObjectTagSet(ctx, bucket, key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(tagSet))
+	for _, tag := range tagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+func (a *AWSTools) PutObjectTags(bucket, key string, tags map[string]string) error {
+	return a.PutObjectTagsWithContext(context.Background(), bucket, key, tags)
+}
+
+// PutObjectTagsWithContext replaces every tag of key with tags.
+func (a *AWSTools) PutObjectTagsWithContext(ctx context.Context, bucket, key string, tags map[string]string) error {
+	_, err := a.s3Client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: newTagSet(tags)},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to set tags of object %q in bucket %q, %w",
+			key, bucket, newOpError("PutObjectTagging", bucket, key, err))
+	}
+
+	return nil
+}
+
+func (a *AWSTools) DeleteObjectTags(bucket, key string) error {
+	return a.DeleteObjectTagsWithContext(context.Background(), bucket, key)
+}
+
+func (a *AWSTools) DeleteObjectTagsWithContext(ctx context.Context, bucket, key string) error {
+	_, err := a.s3Client.DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete tags of object %q in bucket %q, %w",
+			key, bucket, newOpError("DeleteObjectTagging", bucket, key, err))
+	}
+
+	return nil
+}
+
+// getObjectTagSet reads the tags of key, or of one of its versions when
+// versionID is set.
+func (a *AWSTools) getObjectTagSet(ctx context.Context, bucket, key, versionID string) ([]types.Tag, error) {
+	out, err := a.s3Client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: optionalString(versionID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get tags of object %q in bucket %q, %w",
+			key, bucket, newOpError("GetObjectTagging", bucket, key, err))
+	}
+
+	return out.TagSet, nil
+}
+
+// newTagSet converts tags to a tag set sorted by key.
+func newTagSet(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tagSet := make([]types.Tag, 0, len(keys))
+	for _, k := range keys {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return tagSet
+}
+
+// encodeTags encodes tags in the URL query format expected by the
+// x-amz-tagging header.
+func encodeTags(tags map[string]string) string {
+	return encodeTagSet(newTagSet(tags))
+}
+
+// encodeTagSet encodes a tag set for the x-amz-tagging header. Spaces are
+// escaped as %20 rather than "+", which S3 would keep as a literal plus.
+func encodeTagSet(tags []types.Tag) string {
+	pairs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		pairs = append(pairs, escapeTag(aws.ToString(tag.Key))+"="+escapeTag(aws.ToString(tag.Value)))
+	}
+	return strings.Join(pairs, "&")
+}
+
+func escapeTag(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
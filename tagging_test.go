@@ -0,0 +1,46 @@
+package awstools
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestEncodeTagSet(t *testing.T) {
+	tags := []types.Tag{
+		{Key: aws.String("env"), Value: aws.String("prod")},
+		{Key: aws.String("team name"), Value: aws.String("a&b+c")},
+	}
+
+	if got := encodeTagSet(tags); got != "env=prod&team%20name=a%26b%2Bc" {
+		t.Errorf("Unexpected encoded tags: %s", got)
+	}
+}
+
+func TestUploadTags(t *testing.T) {
+	input := &s3.PutObjectInput{}
+	WithUploadTags(map[string]string{"retention": "90 days", "class": "logs"})(input)
+
+	if got := aws.ToString(input.Tagging); got != "class=logs&retention=90%20days" {
+		t.Errorf("Unexpected Tagging header: %s", got)
+	}
+
+	input = &s3.PutObjectInput{}
+	WithUploadTags(nil)(input)
+	if input.Tagging != nil {
+		t.Errorf("Expected no Tagging header for empty tags, got %s", aws.ToString(input.Tagging))
+	}
+}
+
+func TestCopyTags(t *testing.T) {
+	input := newCopyParams(&s3.CopyObjectInput{}, WithCopyTags(map[string]string{"env": "dev"})).input
+
+	if input.TaggingDirective != types.TaggingDirectiveReplace {
+		t.Errorf("Expected REPLACE tagging directive, got %s", input.TaggingDirective)
+	}
+	if got := aws.ToString(input.Tagging); got != "env=dev" {
+		t.Errorf("Unexpected Tagging header: %s", got)
+	}
+}
@@ -0,0 +1,78 @@
+package awstools
+
+import (
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// newUploader returns the uploader shared by the uploads of this instance.
+// Reusing it keeps its pool of part buffers across calls.
+func newUploader(client *s3.Client, params *AWSToolsParams) *manager.Uploader {
+	return manager.NewUploader(client, func(u *manager.Uploader) {
+		applyUploaderParams(u, params)
+	})
+}
+
+func applyUploaderParams(u *manager.Uploader, params *AWSToolsParams) {
+	u.PartSize = params.MultipartUploadPartSize()
+	u.Concurrency = params.MultipartUploadConcurrency()
+	u.LeavePartsOnError = params.MultipartUploadLeavePartsOnError()
+	if provider := params.MultipartUploadBufferProvider(); provider != nil {
+		u.BufferProvider = provider
+	}
+}
+
+// uploaderOptions configures one call of the shared uploader: the instance
+// settings, which may have changed since it was created, then transfer.
+func (a *AWSTools) uploaderOptions(transfer UploadTransfer, tracker *progressTracker) func(*manager.Uploader) {
+	return func(u *manager.Uploader) {
+		applyUploaderParams(u, a.params)
+		if transfer.PartSize > 0 {
+			u.PartSize = transfer.PartSize
+		}
+		if transfer.Concurrency > 0 {
+			u.Concurrency = transfer.Concurrency
+		}
+		if transfer.LeavePartsOnError != nil {
+			u.LeavePartsOnError = *transfer.LeavePartsOnError
+		}
+		if transfer.BufferProvider != nil {
+			u.BufferProvider = transfer.BufferProvider
+		}
+		u.ClientOptions = append(u.ClientOptions, tracker.clientOptions()...)
+	}
+}
+
+// newDownloader returns the downloader shared by the downloads of this
+// instance.
+func newDownloader(client *s3.Client, params *AWSToolsParams) *manager.Downloader {
+	return manager.NewDownloader(client, func(d *manager.Downloader) {
+		applyDownloaderParams(d, params)
+	})
+}
+
+func applyDownloaderParams(d *manager.Downloader, params *AWSToolsParams) {
+	d.PartSize = params.MultipartDownloadPartSize()
+	d.Concurrency = params.MultipartDownloadConcurrency()
+	if provider := params.MultipartDownloadBufferProvider(); provider != nil {
+		d.BufferProvider = provider
+	}
+}
+
+// downloaderOptions configures one call of the shared downloader: the
+// instance settings, then p.
+func (a *AWSTools) downloaderOptions(p *downloadParams, tracker *progressTracker) func(*manager.Downloader) {
+	return func(d *manager.Downloader) {
+		applyDownloaderParams(d, a.params)
+		if p.partSize > 0 {
+			d.PartSize = p.partSize
+		}
+		if p.concurrency > 0 {
+			d.Concurrency = p.concurrency
+		}
+		if p.bufferProvider != nil {
+			d.BufferProvider = p.bufferProvider
+		}
+		d.ClientOptions = append(d.ClientOptions, tracker.clientOptions()...)
+	}
+}
@@ -0,0 +1,50 @@
+package awstools
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestTransferOptions(t *testing.T) {
+	params, err := newAWSToolsParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.MultipartUploadPartSize() != manager.DefaultUploadPartSize ||
+		params.MultipartDownloadConcurrency() != manager.DefaultDownloadConcurrency {
+		t.Error("Expected the SDK defaults for multipart transfers")
+	}
+
+	if _, err := newAWSToolsParams(WithMultipartUploadPartSize(1024)); err == nil {
+		t.Error("Expected an error for a part size below 5 MiB")
+	}
+	if _, err := newAWSToolsParams(WithMultipartDownloadConcurrency(0)); err == nil {
+		t.Error("Expected an error for a zero concurrency")
+	}
+}
+
+func TestUploaderOptions(t *testing.T) {
+	params, err := newAWSToolsParams(
+		WithMultipartUploadPartSize(8*1024*1024),
+		WithMultipartUploadConcurrency(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &AWSTools{params: params}
+
+	u := manager.Uploader{}
+	a.uploaderOptions(UploadTransfer{Concurrency: 7, LeavePartsOnError: aws.Bool(true)}, nil)(&u)
+	if u.PartSize != 8*1024*1024 || u.Concurrency != 7 || !u.LeavePartsOnError {
+		t.Errorf("Unexpected uploader settings: part size %d, concurrency %d", u.PartSize, u.Concurrency)
+	}
+
+	d := manager.Downloader{}
+	a.downloaderOptions(newDownloadParams(&s3.GetObjectInput{}, WithDownloadPartSize(1024)), nil)(&d)
+	if d.PartSize != 1024 || d.Concurrency != manager.DefaultDownloadConcurrency {
+		t.Errorf("Unexpected downloader settings: part size %d, concurrency %d", d.PartSize, d.Concurrency)
+	}
+}
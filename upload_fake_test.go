@@ -0,0 +1,53 @@
+package awstools_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/thiagozs/go-awstools"
+	"github.com/thiagozs/go-awstools/awstoolstest"
+)
+
+func TestUploadTransferWithCustomOption(t *testing.T) {
+	tools, srv := awstoolstest.New(t)
+	srv.CreateBucket("bucket")
+
+	data := bytes.Repeat([]byte("u"), 11*1024*1024)
+	path := filepath.Join(t.TempDir(), "big")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Callers can still write their own options against the input.
+	custom := awstools.UploadOption(func(input *s3.PutObjectInput) {
+		input.ContentType = aws.String("application/x-custom")
+	})
+
+	var last awstools.TransferProgress
+	err := tools.UploadFileToS3WithTransfer("bucket", "big", path,
+		awstools.UploadTransfer{
+			PartSize: 5 * 1024 * 1024,
+			Progress: func(p awstools.TransferProgress) { last = p },
+		}, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored, _ := srv.Object("bucket", "big"); !bytes.Equal(stored, data) {
+		t.Error("Stored object differs from the file")
+	}
+	info, err := tools.StatObject("bucket", "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ContentType != "application/x-custom" {
+		t.Errorf("Expected the custom option to apply, got %q", info.ContentType)
+	}
+	if !last.Done || last.Transferred != int64(len(data)) || last.PartsCompleted != 3 {
+		t.Errorf("Unexpected final progress: %+v", last)
+	}
+}
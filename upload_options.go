@@ -2,6 +2,7 @@ package awstools
 
 import (
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
@@ -65,3 +66,104 @@ func WithUploadACL(acl types.ObjectCannedACL) UploadOption {
 		input.ACL = acl
 	}
 }
+
+// WithUploadTags tags the uploaded object with tags.
+func WithUploadTags(tags map[string]string) UploadOption {
+	return func(input *s3.PutObjectInput) {
+		if len(tags) == 0 {
+			return
+		}
+		input.Tagging = aws.String(encodeTags(tags))
+	}
+}
+
+// WithUploadSSES3 encrypts the object with S3 managed keys (SSE-S3).
+func WithUploadSSES3() UploadOption {
+	return func(input *s3.PutObjectInput) {
+		input.ServerSideEncryption = types.ServerSideEncryptionAes256
+	}
+}
+
+// WithUploadSSEKMS encrypts the object with a KMS key (SSE-KMS). An empty
+// keyID selects the AWS managed key of the account.
+func WithUploadSSEKMS(keyID string) UploadOption {
+	return func(input *s3.PutObjectInput) {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = optionalString(keyID)
+	}
+}
+
+// WithUploadSSEKMSContext sets the encryption context of an SSE-KMS upload.
+func WithUploadSSEKMSContext(encryptionContext map[string]string) UploadOption {
+	return func(input *s3.PutObjectInput) {
+		input.SSEKMSEncryptionContext = encodeKMSContext(encryptionContext)
+	}
+}
+
+// WithUploadBucketKey enables or disables the S3 Bucket Key for an SSE-KMS
+// upload, overriding the bucket setting.
+func WithUploadBucketKey(enabled bool) UploadOption {
+	return func(input *s3.PutObjectInput) {
+		input.BucketKeyEnabled = aws.Bool(enabled)
+	}
+}
+
+// WithUploadSSECustomerKey encrypts the object with a 256-bit customer
+// provided key (SSE-C). The same key is needed to read the object back. The
+// upload fails with ErrInvalidSSECustomerKey if key is not 32 bytes long.
+func WithUploadSSECustomerKey(key []byte) UploadOption {
+	return func(input *s3.PutObjectInput) {
+		sse := newSSECustomerKey(key)
+		input.SSECustomerAlgorithm = sse.algorithm
+		input.SSECustomerKey = sse.key
+		input.SSECustomerKeyMD5 = sse.keyMD5
+	}
+}
+
+// WithUploadChecksum has the SDK compute a flexible checksum, e.g. CRC32C or
+// SHA256, and send it for S3 to verify. Multipart uploads get a checksum per
+// part and a composite checksum of the parts.
+func WithUploadChecksum(algorithm types.ChecksumAlgorithm) UploadOption {
+	return func(input *s3.PutObjectInput) {
+		input.ChecksumAlgorithm = algorithm
+	}
+}
+
+// WithUploadSHA256Metadata stores the SHA256 of the full object in its
+// metadata, for endpoints without flexible checksums. The digest is computed
+// from the file before it is sent.
+func WithUploadSHA256Metadata() UploadOption {
+	return func(input *s3.PutObjectInput) {
+		if input.Metadata == nil {
+			input.Metadata = make(map[string]string, 1)
+		}
+		input.Metadata[metaSHA256] = ""
+	}
+}
+
+// UploadTransfer tunes how one upload is sent. Zero fields keep the settings
+// of the AWSTools instance.
+type UploadTransfer struct {
+	// Progress receives the progress of the upload.
+	Progress ProgressFunc
+	// PartSize overrides the multipart part size. The minimum is 5 MiB.
+	PartSize int64
+	// Concurrency overrides how many parts are sent in parallel.
+	Concurrency int
+	// LeavePartsOnError overrides whether the parts of a failed upload are
+	// kept.
+	LeavePartsOnError *bool
+	// BufferProvider overrides the buffer provider of the upload.
+	BufferProvider manager.ReadSeekerWriteToProvider
+}
+
+// applyUploadOptions applies opts to input, which already names the object
+// and holds its body.
+func applyUploadOptions(input *s3.PutObjectInput, opts []UploadOption) *s3.PutObjectInput {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(input)
+		}
+	}
+	return input
+}
@@ -0,0 +1,187 @@
+package awstools
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectVersion is one entry of the version history of a bucket: either a
+// stored version of an object or a delete marker.
+type ObjectVersion struct {
+	Key            string
+	VersionID      string
+	IsLatest       bool
+	IsDeleteMarker bool
+	Size           int64
+	ETag           string
+	LastModified   time.Time
+	StorageClass   types.ObjectVersionStorageClass
+}
+
+func (a *AWSTools) ListObjectVersions(bucket, prefix string) iter.Seq2[ObjectVersion, error] {
+	return a.ListObjectVersionsWithContext(context.Background(), bucket, prefix)
+}
+
+// ListObjectVersionsWithContext iterates over every version and delete marker
+// under prefix, ordered by key and, within a key, from newest to oldest.
+// Pages are fetched as the iteration advances; a listing failure is yielded
+// once as the error and ends the iteration.
+func (a *AWSTools) ListObjectVersionsWithContext(ctx context.Context, bucket, prefix string) iter.Seq2[ObjectVersion, error] {
+	return func(yield func(ObjectVersion, error) bool) {
+		paginator := s3.NewListObjectVersionsPaginator(a.s3Client, &s3.ListObjectVersionsInput{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		})
+
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				yield(ObjectVersion{}, fmt.Errorf("unable to list object versions in bucket %q, %w",
+					bucket, newOpError("ListObjectVersions", bucket, prefix, err)))
+				return
+			}
+
+			for _, version := range mergeVersionPage(page) {
+				if !yield(version, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// mergeVersionPage interleaves the versions and delete markers of a page,
+// which S3 returns as separate lists.
+func mergeVersionPage(page *s3.ListObjectVersionsOutput) []ObjectVersion {
+	versions := make([]ObjectVersion, 0, len(page.Versions)+len(page.DeleteMarkers))
+	for _, v := range page.Versions {
+		versions = append(versions, ObjectVersion{
+			Key:          aws.ToString(v.Key),
+			VersionID:    aws.ToString(v.VersionId),
+			IsLatest:     aws.ToBool(v.IsLatest),
+			Size:         aws.ToInt64(v.Size),
+			ETag:         trimETag(aws.ToString(v.ETag)),
+			LastModified: aws.ToTime(v.LastModified),
+			StorageClass: v.StorageClass,
+		})
+	}
+	for _, m := range page.DeleteMarkers {
+		versions = append(versions, ObjectVersion{
+			Key:            aws.ToString(m.Key),
+			VersionID:      aws.ToString(m.VersionId),
+			IsLatest:       aws.ToBool(m.IsLatest),
+			IsDeleteMarker: true,
+			LastModified:   aws.ToTime(m.LastModified),
+		})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		if versions[i].Key != versions[j].Key {
+			return versions[i].Key < versions[j].Key
+		}
+		return versions[i].LastModified.After(versions[j].LastModified)
+	})
+
+	return versions
+}
+
+func (a *AWSTools) StatObjectVersion(bucket, key, versionID string) (*ObjectInfo, error) {
+	return a.StatObjectVersionWithContext(context.Background(), bucket, key, versionID)
+}
+
+func (a *AWSTools) StatObjectVersionWithContext(ctx context.Context, bucket, key, versionID string) (*ObjectInfo, error) {
+	return a.statObject(ctx, &s3.HeadObjectInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	})
+}
+
+func (a *AWSTools) DeleteObjectVersion(bucket, key, versionID string) error {
+	return a.DeleteObjectVersionWithContext(context.Background(), bucket, key, versionID)
+}
+
+// DeleteObjectVersionWithContext permanently removes one version of key.
+// Deleting a delete marker makes the previous version current again.
+func (a *AWSTools) DeleteObjectVersionWithContext(ctx context.Context, bucket, key, versionID string) error {
+	_, err := a.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete version %q of object %q from bucket %q, %w",
+			versionID, key, bucket, newOpError("DeleteObject", bucket, key, err))
+	}
+
+	return nil
+}
+
+func (a *AWSTools) RestoreVersion(bucket, key, versionID string) (*CopyResult, error) {
+	return a.RestoreVersionWithContext(context.Background(), bucket, key, versionID)
+}
+
+// RestoreVersionWithContext makes versionID the current version of key by
+// copying it over the object. The history is kept: the restored content is
+// stored as a new version.
+func (a *AWSTools) RestoreVersionWithContext(ctx context.Context, bucket, key, versionID string) (*CopyResult, error) {
+	return a.CopyObjectWithContext(ctx, bucket, key, bucket, key, WithCopySourceVersion(versionID))
+}
+
+func (a *AWSTools) EnableBucketVersioning(bucket string) error {
+	return a.EnableBucketVersioningWithContext(context.Background(), bucket)
+}
+
+func (a *AWSTools) EnableBucketVersioningWithContext(ctx context.Context, bucket string) error {
+	return a.putBucketVersioning(ctx, bucket, types.BucketVersioningStatusEnabled)
+}
+
+func (a *AWSTools) SuspendBucketVersioning(bucket string) error {
+	return a.SuspendBucketVersioningWithContext(context.Background(), bucket)
+}
+
+// SuspendBucketVersioningWithContext stops creating new versions. Existing
+// versions are kept; versioning cannot be disabled once enabled.
+func (a *AWSTools) SuspendBucketVersioningWithContext(ctx context.Context, bucket string) error {
+	return a.putBucketVersioning(ctx, bucket, types.BucketVersioningStatusSuspended)
+}
+
+func (a *AWSTools) putBucketVersioning(ctx context.Context, bucket string, status types.BucketVersioningStatus) error {
+	_, err := a.s3Client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucket),
+		VersioningConfiguration: &types.VersioningConfiguration{
+			Status: status,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to set versioning of bucket %q to %s, %w",
+			bucket, status, newOpError("PutBucketVersioning", bucket, "", err))
+	}
+
+	return nil
+}
+
+func (a *AWSTools) GetBucketVersioning(bucket string) (types.BucketVersioningStatus, error) {
+	return a.GetBucketVersioningWithContext(context.Background(), bucket)
+}
+
+// GetBucketVersioningWithContext returns the versioning status of bucket. It
+// is empty for a bucket that never had versioning enabled.
+func (a *AWSTools) GetBucketVersioningWithContext(ctx context.Context, bucket string) (types.BucketVersioningStatus, error) {
+	out, err := a.s3Client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{
+		Bucket: aws.String(bucket),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to get versioning of bucket %q, %w",
+			bucket, newOpError("GetBucketVersioning", bucket, "", err))
+	}
+
+	return out.Status, nil
+}
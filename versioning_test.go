@@ -0,0 +1,69 @@
+package awstools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestMergeVersionPage(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	page := &s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{
+			{Key: aws.String("a.txt"), VersionId: aws.String("a2"), LastModified: aws.Time(t0.Add(time.Hour)), ETag: aws.String(`"e2"`), Size: aws.Int64(2)},
+			{Key: aws.String("a.txt"), VersionId: aws.String("a1"), LastModified: aws.Time(t0), ETag: aws.String(`"e1"`), Size: aws.Int64(1)},
+			{Key: aws.String("b.txt"), VersionId: aws.String("b1"), LastModified: aws.Time(t0), IsLatest: aws.Bool(true)},
+		},
+		DeleteMarkers: []types.DeleteMarkerEntry{
+			{Key: aws.String("a.txt"), VersionId: aws.String("dm"), LastModified: aws.Time(t0.Add(2 * time.Hour)), IsLatest: aws.Bool(true)},
+		},
+	}
+
+	versions := mergeVersionPage(page)
+
+	expected := []string{"dm", "a2", "a1", "b1"}
+	if len(versions) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(versions))
+	}
+	for i, id := range expected {
+		if versions[i].VersionID != id {
+			t.Errorf("Entry %d: expected version %s, got %s", i, id, versions[i].VersionID)
+		}
+	}
+
+	if !versions[0].IsDeleteMarker || !versions[0].IsLatest {
+		t.Errorf("Expected latest delete marker first, got %+v", versions[0])
+	}
+	if versions[1].IsDeleteMarker || versions[1].ETag != "e2" || versions[1].Size != 2 {
+		t.Errorf("Unexpected version entry: %+v", versions[1])
+	}
+}
+
+func TestCopySourceVersion(t *testing.T) {
+	input := newCopyParams(&s3.CopyObjectInput{CopySource: aws.String(copySource("bucket", "dir/a?b.txt"))},
+		WithCopySourceVersion("v1"),
+		WithCopySourceVersion("v+2"),
+	).input
+
+	if got := aws.ToString(input.CopySource); got != "bucket/dir/a%3Fb.txt?versionId=v%2B2" {
+		t.Errorf("Unexpected copy source: %s", got)
+	}
+	if got := aws.ToString(sourceVersion(input.CopySource)); got != "v+2" {
+		t.Errorf("Expected version v+2, got %q", got)
+	}
+	if sourceVersion(aws.String("bucket/key")) != nil {
+		t.Error("Expected no version for an unversioned copy source")
+	}
+}
+
+func TestDownloadOptions(t *testing.T) {
+	input := newDownloadParams(&s3.GetObjectInput{}, WithDownloadVersion("v1")).input
+
+	if aws.ToString(input.VersionId) != "v1" {
+		t.Errorf("Expected version v1, got %q", aws.ToString(input.VersionId))
+	}
+}